@@ -0,0 +1,178 @@
+package opts
+
+import (
+	"errors"
+	"strings"
+)
+
+// A Policy describes how the repeated occurrences of an option are
+// treated.
+//
+// The LastWins, FirstWins, and AtMostOnce policies are enforced by
+// [Parser.Parse]: of the flags of an option with the LastWins or
+// FirstWins policy only the last or first is returned, while giving an
+// option with the AtMostOnce policy more than once results in a
+// [DuplicateOptionError].
+//
+// The Count, Append, Split, and Map policies do not affect parsing;
+// every occurrence of such an option is returned as a flag, and the
+// flags are combined into a [Value] by [Accumulate].  The arguments of
+// options with the Map policy must be of the form ‘key=value’, otherwise
+// [Parser.Parse] returns a [ValueError].
+type Policy int
+
+// These tokens can be used to specify the Policy of a [LongOpt].
+const (
+	Each       Policy = iota // every occurrence is returned
+	Count                    // occurrences are counted
+	Append                   // arguments are collected into a list
+	Split                    // arguments are split on Sep into a list
+	Map                      // ‘key=value’ arguments are collected into a map
+	LastWins                 // only the last occurrence is returned
+	FirstWins                // only the first occurrence is returned
+	AtMostOnce               // repeated occurrences are an error
+)
+
+// A Value holds the accumulated occurrences of an option, as returned by
+// [Accumulate].
+type Value struct {
+	Count int               // the number of times the option was given
+	List  []string          // the arguments of an Append or Split option
+	Map   map[string]string // the arguments of a Map option
+}
+
+var errNotPair = errors.New("expected ‘key=value’")
+
+// Accumulate combines the flags in flags, as parsed according to opts,
+// according to the Policy of each option.  The resulting values are keyed
+// by the options long-form, or by their short-form as a string for
+// options without a long-form.  Options which do not appear in flags are
+// absent from the returned map.
+//
+// Every flag of an option increments its Count.  The arguments of
+// options with the Append policy are appended to List, while those of
+// options with the Split policy are first split on the options Sep, or on
+// ‘,’ if Sep is empty.  The arguments of options with the Map policy are
+// split on the first ‘=’ into a key and value which are stored in Map,
+// with later keys replacing earlier ones.  A negated flag resets the
+// value of the option it negates, such that ‘-vv --no-verbose -v’ results
+// in a Count of 1.
+func Accumulate(flags []Flag, opts []LongOpt) map[string]Value {
+	vs := make(map[string]Value)
+	for _, f := range flags {
+		if f.Index < 0 || f.Index >= len(opts) {
+			continue
+		}
+		o := opts[f.Index]
+		k := optKey(o)
+		if f.Negated {
+			vs[k] = Value{}
+			continue
+		}
+
+		v := vs[k]
+		v.Count++
+		switch {
+		case !hasArg(o, f):
+		case o.Policy == Append:
+			v.List = append(v.List, f.Value)
+		case o.Policy == Split:
+			sep := o.Sep
+			if sep == "" {
+				sep = ","
+			}
+			v.List = append(v.List, strings.Split(f.Value, sep)...)
+		case o.Policy == Map:
+			if v.Map == nil {
+				v.Map = make(map[string]string)
+			}
+			key, val, _ := strings.Cut(f.Value, "=")
+			v.Map[key] = val
+		}
+		vs[k] = v
+	}
+	return vs
+}
+
+// applyPolicies returns the flags in flags with the LastWins, FirstWins,
+// and AtMostOnce policies of their options applied, and the arguments of
+// options with the Map policy validated.  Unless p.CollectErrors is set,
+// only the first error is returned.
+func (p *Parser) applyPolicies(flags []Flag) ([]Flag, error) {
+	first := make([]int, len(p.opts))
+	last := make([]int, len(p.opts))
+	for i := range first {
+		first[i], last[i] = -1, -1
+	}
+	for i, f := range flags {
+		if f.Index >= 0 {
+			if first[f.Index] == -1 {
+				first[f.Index] = i
+			}
+			last[f.Index] = i
+		}
+	}
+
+	var fs []Flag
+	var errs []error
+	for i, f := range flags {
+		if f.Index < 0 {
+			fs = append(fs, f)
+			continue
+		}
+		switch o := p.opts[f.Index]; {
+		case o.Policy == FirstWins && i != first[f.Index],
+			o.Policy == LastWins && i != last[f.Index]:
+			continue
+		case o.Policy == AtMostOnce && i != first[f.Index]:
+			e := DuplicateOptionError{
+				Pos:   f.Pos,
+				First: flags[first[f.Index]].Pos,
+				Plus:  f.Plus,
+			}
+			switch {
+			case f.IsLong:
+				e.Long = strings.TrimLeft(f.Name, "-+")
+				e.OneDash = len(f.Name)-len(e.Long) == 1
+			case isNumber(o, f):
+				e.Name = f.Name
+			default:
+				e.Short = f.Key
+			}
+			if !p.CollectErrors {
+				return nil, e
+			}
+			errs = append(errs, e)
+			continue
+		case o.Policy == Map && !f.Negated && hasArg(o, f) &&
+			!strings.Contains(f.Value, "="):
+			e := ValueError{Flag: f, Err: errNotPair}
+			if !p.CollectErrors {
+				return nil, e
+			}
+			errs = append(errs, e)
+			continue
+		}
+		fs = append(fs, f)
+	}
+	return fs, errors.Join(errs...)
+}
+
+// hasArg reports whether the flag f of the option o was given an
+// argument.
+func hasArg(o LongOpt, f Flag) bool {
+	switch o.Arg {
+	case Required:
+		return true
+	case Optional:
+		return f.Inline || f.Source != FromArgs
+	}
+	return o.Numeric && f.Inline
+}
+
+// isNumber reports whether the flag f of the option o was given in its
+// numeric form, such as ‘-5’.
+func isNumber(o LongOpt, f Flag) bool {
+	return o.Numeric && !f.IsLong && f.Source == FromArgs &&
+		strings.TrimLeft(f.Name, "-+") != string(o.Short)
+}
@@ -0,0 +1,116 @@
+package opts
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var accumOpts = []LongOpt{
+	{Short: 'v', Long: "verbose", Arg: None, Policy: Count, Negatable: true},
+	{Short: 'I', Arg: Required, Policy: Append},
+	{Short: -1, Long: "tags", Arg: Required, Policy: Split, Sep: ":"},
+	{Short: 'D', Long: "define", Arg: Required, Policy: Map},
+	{Short: 'o', Long: "output", Arg: Required, Policy: LastWins},
+	{Short: 'c', Long: "config", Arg: Required, Policy: FirstWins},
+	{Short: 'n', Long: "dry-run", Arg: None, Policy: AtMostOnce},
+}
+
+func TestAccumulate(t *testing.T) {
+	args := []string{"foo", "-vvIinc", "--tags=a:b", "-D", "x=1", "-vI", "/usr",
+		"--tags", "c", "--def=y=2=3", "-Dx=4", "-n"}
+	flags, _, err := GetLong(args, accumOpts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := map[string]Value{
+		"verbose": {Count: 3},
+		"I":       {Count: 2, List: []string{"inc", "/usr"}},
+		"tags":    {Count: 2, List: []string{"a", "b", "c"}},
+		"define":  {Count: 3, Map: map[string]string{"x": "4", "y": "2=3"}},
+		"dry-run": {Count: 1},
+	}
+	if vs := Accumulate(flags, accumOpts); !reflect.DeepEqual(vs, want) {
+		die(t, "Accumulate()", want, vs)
+	}
+}
+
+func TestAccumulateNegated(t *testing.T) {
+	args := []string{"foo", "-vv", "--no-verbose", "-v"}
+	flags, _, err := GetLong(args, accumOpts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if v := Accumulate(flags, accumOpts)["verbose"]; v.Count != 1 {
+		die(t, "Count", 1, v.Count)
+	}
+}
+
+func TestFirstLastWins(t *testing.T) {
+	args := []string{"foo", "-oa", "-cx", "--out=b", "-v", "--conf", "y", "-o", "c"}
+	flags, _, err := GetLong(args, accumOpts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 3 {
+		die(t, "flags", []rune{'c', 'v', 'o'}, flags)
+	}
+	if f := flags[0]; f.Key != 'c' || f.Value != "x" {
+		die(t, "flags[0]", Flag{Key: 'c', Value: "x"}, f)
+	}
+	if f := flags[2]; f.Key != 'o' || f.Value != "c" || f.Pos != 7 {
+		die(t, "flags[2]", Flag{Key: 'o', Value: "c", Pos: 7}, f)
+	}
+}
+
+func TestAtMostOnce(t *testing.T) {
+	_, _, err := GetLong([]string{"foo", "-n", "-v", "--dry"}, accumOpts)
+	want := DuplicateOptionError{Long: "dry", Pos: 3, First: 1}
+	if err != want {
+		die(t, "err", want, err)
+	}
+	if s := err.Error(); s != "option ‘--dry’ given more than once" {
+		die(t, "err.Error()", "option ‘--dry’ given more than once", s)
+	}
+}
+
+func TestAtMostOnceNumeric(t *testing.T) {
+	for _, short := range []rune{-1, 'n'} {
+		opts := []LongOpt{
+			{Short: short, Long: "lines", Arg: Required, Numeric: true, Policy: AtMostOnce},
+		}
+		_, _, err := GetLong([]string{"foo", "-5", "-6"}, opts)
+		want := DuplicateOptionError{Name: "-6", Pos: 2, First: 1}
+		if err != want {
+			die(t, "err", want, err)
+		}
+		if s := err.Error(); s != "numeric option ‘-6’ given more than once" {
+			die(t, "err.Error()", "numeric option ‘-6’ given more than once", s)
+		}
+	}
+
+	opts := []LongOpt{
+		{Short: 'n', Long: "lines", Arg: Required, Numeric: true, Policy: AtMostOnce},
+	}
+	_, _, err := GetLong([]string{"foo", "-5", "-n6"}, opts)
+	if want := (DuplicateOptionError{Short: 'n', Pos: 2, First: 1}); err != want {
+		die(t, "err", want, err)
+	}
+}
+
+func TestMapNotPair(t *testing.T) {
+	p := NewLongParser(accumOpts)
+	p.CollectErrors = true
+	flags, _, err := p.Parse([]string{"foo", "-Dx", "-nn", "-Dy=1"})
+	if len(flags) != 2 {
+		die(t, "flags", []rune{'n', 'D'}, flags)
+	}
+	errs := Errors(err)
+	if len(errs) != 2 || errs[1] != (DuplicateOptionError{Short: 'n', Pos: 2, First: 2}) {
+		die(t, "Errors(err)", "2 errors", errs)
+	}
+	var e ValueError
+	if !errors.As(errs[0], &e) || e.Flag.Name != "-D" {
+		die(t, "errs[0]", "invalid argument ‘x’ for option ‘-D’", errs[0])
+	}
+}
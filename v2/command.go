@@ -0,0 +1,166 @@
+package opts
+
+import (
+	"slices"
+	"strings"
+)
+
+// A Command describes a command in a tree of subcommands, such as those
+// of git(1) where a command-line takes the form ‘tool [global options]
+// <command> [command options] [args]’.
+//
+// Opts holds the options of the command.  Persistent holds options that
+// are accepted by both the command and all of its descendants, such as a
+// global ‘--verbose’.  The subcommands of the command are listed in
+// Commands, and the command has no subcommands if Commands is empty.
+type Command struct {
+	Name       string
+	Help       string // a description of the command
+	Opts       []LongOpt
+	Persistent []LongOpt
+	Commands   []*Command
+}
+
+// Parse parses the command-line arguments in args according to c and its
+// subcommands.  The first element of args is assumed to be the program
+// name and is skipped.
+//
+// The arguments are first parsed according to the options of c as they
+// would be by [GetLong].  If c has subcommands the first non-option
+// argument is then taken to be the name of a subcommand, and the
+// arguments that follow are parsed according to the options of that
+// subcommand.  This repeats until a command without subcommands is
+// reached, there are no arguments left, or ‘--’ is reached; the
+// arguments following ‘--’ are never taken to be a subcommand.  Much like
+// long-options, commands may be abbreviated so long as they are
+// unambiguous.
+//
+// A successful parse returns the commands that were invoked in path,
+// starting with c, and the flags given to each of those commands in
+// flags, such that flags[i] holds the flags given to path[i].  Flags are
+// parsed according to the options of the command followed by the
+// persistent options of the command and then those of its ancestors,
+// nearest first; the Index of each flag is relative to this ordering.
+// The remaining non-option arguments are returned in rest.
+//
+// The LastWins, FirstWins, AtMostOnce, and Map policies of the options
+// are applied to the flags of each command separately, as they would be
+// by [Parser.Parse].  Unlike [Parser.Parse] however, the Env of the
+// options is never consulted.
+//
+// In the case of failure, err will be one of the errors returned by
+// [GetLong], a [DuplicateOptionError] or [ValueError] as described by
+// [Policy], or a [BadCommandError] or [AmbiguousCommandError].
+func (c *Command) Parse(args []string) (path []*Command, flags [][]Flag, rest []string, err error) {
+	if len(args) == 0 {
+		return
+	}
+
+	var inherited []LongOpt
+	for cmd, i := c, 0; ; {
+		inherited = append(slices.Clip(cmd.Persistent), inherited...)
+		spec := append(slices.Clip(cmd.Opts), inherited...)
+
+		var fs []Flag
+		p := NewLongParser(spec)
+		it := p.iterAt(args, i+1)
+		for {
+			f, ok, err := it.Next()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if !ok {
+				break
+			}
+			fs = append(fs, f)
+		}
+		if fs, err = p.applyPolicies(fs); err != nil {
+			return nil, nil, nil, err
+		}
+
+		path = append(path, cmd)
+		flags = append(flags, fs)
+		rest = it.Rest()
+		if len(cmd.Commands) == 0 || len(rest) == 0 || it.s.dashes {
+			return path, flags, rest, nil
+		}
+
+		i = it.Optind()
+		if cmd, err = findCommand(cmd.Commands, rest[0], i); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+}
+
+func findCommand(cmds []*Command, name string, pos int) (*Command, error) {
+	var match *Command
+	var cands []string
+	for _, c := range cmds {
+		if c.Name == name {
+			return c, nil
+		}
+		if strings.HasPrefix(c.Name, name) {
+			match = c
+			cands = append(cands, c.Name)
+		}
+	}
+	switch len(cands) {
+	case 0:
+		return nil, BadCommandError{Name: name, Pos: pos}
+	case 1:
+		return match, nil
+	}
+	return nil, AmbiguousCommandError{Name: name, Pos: pos, Candidates: cands}
+}
+
+// Usage returns a help message for the last command in path, where path
+// is a sequence of commands as returned by [Command.Parse].  The message
+// consists of a synopsis of the command, its Help, a table of the options
+// it accepts as produced by [Help], and a list of its subcommands.
+func Usage(path []*Command) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	var names []string
+	var opts []LongOpt
+	for _, c := range path {
+		names = append(names, c.Name)
+		opts = append(slices.Clip(c.Persistent), opts...)
+	}
+	c := path[len(path)-1]
+	opts = append(slices.Clip(c.Opts), opts...)
+
+	var sb strings.Builder
+	sb.WriteString("Usage: " + strings.Join(names, " "))
+	if s := Synopsis(opts); s != "" {
+		sb.WriteString(" " + s)
+	}
+	if len(c.Commands) > 0 {
+		sb.WriteString(" <command> [args]")
+	}
+	sb.WriteByte('\n')
+
+	if c.Help != "" {
+		sb.WriteString("\n" + c.Help + "\n")
+	}
+	if len(opts) > 0 {
+		sb.WriteString("\nOptions:\n" + Help(opts))
+	}
+	if len(c.Commands) > 0 {
+		width := 0
+		for _, sc := range c.Commands {
+			width = max(width, stringWidth(sc.Name))
+		}
+		sb.WriteString("\nCommands:\n")
+		for _, sc := range c.Commands {
+			sb.WriteString("  " + sc.Name)
+			if sc.Help != "" {
+				pad := width - stringWidth(sc.Name) + 2
+				sb.WriteString(strings.Repeat(" ", pad) + sc.Help)
+			}
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+package opts
+
+import (
+	"slices"
+	"testing"
+)
+
+var testCommand = &Command{
+	Name:       "tool",
+	Persistent: []LongOpt{{Short: 'v', Long: "verbose", Arg: None}},
+	Opts:       []LongOpt{{Short: 'C', Long: "directory", Arg: Required}},
+	Commands: []*Command{
+		{
+			Name: "remote",
+			Help: "manage remotes",
+			Commands: []*Command{
+				{
+					Name: "add",
+					Help: "add a remote",
+					Opts: []LongOpt{{Short: 'f', Long: "fetch", Arg: None}},
+				},
+				{Name: "remove", Help: "remove a remote"},
+			},
+		},
+		{Name: "reset", Help: "reset the current head"},
+		{Name: "λεωνίδας", Help: "unicode"},
+	},
+}
+
+func TestCommandParse(t *testing.T) {
+	args := []string{"tool", "-C", "dir", "remote", "-v", "add", "--fe", "-v",
+		"origin", "-x"}
+	path, flags, rest, err := testCommand.Parse(args)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	var names []string
+	for _, c := range path {
+		names = append(names, c.Name)
+	}
+	if want := []string{"tool", "remote", "add"}; !slices.Equal(names, want) {
+		die(t, "path", want, names)
+	}
+	if len(flags) != 3 || len(flags[0]) != 1 || len(flags[1]) != 1 || len(flags[2]) != 2 {
+		die(t, "flags", "[[-C] [-v] [--fe -v]]", flags)
+	}
+	if f := flags[2][0]; f.Key != 'f' || f.Pos != 6 {
+		die(t, "flags[2][0]", Flag{Key: 'f', Pos: 6}, f)
+	}
+	if f := flags[2][1]; f.Key != 'v' || f.Long != "verbose" || f.Pos != 7 {
+		die(t, "flags[2][1]", Flag{Key: 'v', Long: "verbose", Pos: 7}, f)
+	}
+	if want := []string{"origin", "-x"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+}
+
+func TestCommandPrefix(t *testing.T) {
+	path, _, _, err := testCommand.Parse([]string{"tool", "λ"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(path) != 2 || path[1].Name != "λεωνίδας" {
+		die(t, "path[1].Name", "λεωνίδας", path)
+	}
+	_, _, _, err = testCommand.Parse([]string{"tool", "-v", "re"})
+	want := "command ‘re’ is ambiguous; possibilities: ‘remote’ ‘reset’"
+	if err == nil || err.Error() != want {
+		die(t, "err", want, err)
+	}
+	_, _, _, err = testCommand.Parse([]string{"tool", "remote", "rename"})
+	if err != (BadCommandError{Name: "rename", Pos: 2}) {
+		die(t, "err", BadCommandError{Name: "rename", Pos: 2}, err)
+	}
+}
+
+func TestCommandDashes(t *testing.T) {
+	path, flags, rest, err := testCommand.Parse([]string{"tool", "-v", "--", "remote", "-v"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(path) != 1 || len(flags) != 1 || len(flags[0]) != 1 {
+		die(t, "path", []string{"tool"}, path)
+	}
+	if want := []string{"remote", "-v"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	path := []*Command{testCommand, testCommand.Commands[0]}
+	want := `Usage: tool remote [-v] <command> [args]
+
+manage remotes
+
+Options:
+  -v, --verbose
+
+Commands:
+  add     add a remote
+  remove  remove a remote
+`
+	if s := Usage(path); s != want {
+		die(t, "Usage()", want, s)
+	}
+}
+
+func TestCommandPolicy(t *testing.T) {
+	cmd := &Command{
+		Name:       "tool",
+		Persistent: []LongOpt{{Short: 'o', Long: "output", Arg: Required, Policy: LastWins}},
+		Commands: []*Command{{
+			Name: "run",
+			Opts: []LongOpt{{Short: 'n', Long: "name", Arg: Required, Policy: AtMostOnce}},
+		}},
+	}
+	_, flags, _, err := cmd.Parse([]string{"tool", "-oa", "-ob", "run", "-oc"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags[0]) != 1 || flags[0][0].Value != "b" {
+		die(t, "flags[0]", "[-ob]", flags[0])
+	}
+	if len(flags[1]) != 1 || flags[1][0].Value != "c" {
+		die(t, "flags[1]", "[-oc]", flags[1])
+	}
+
+	_, _, _, err = cmd.Parse([]string{"tool", "run", "-nx", "--name=y"})
+	want := DuplicateOptionError{Long: "name", Pos: 3, First: 2}
+	if err != want {
+		die(t, "err", want, err)
+	}
+}
@@ -0,0 +1,383 @@
+package opts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A Shell represents a shell for which completion scripts can be
+// generated with [Completion].
+type Shell int
+
+// These tokens can be used to specify the shell to generate a completion
+// script for.
+const (
+	Bash Shell = iota // GNU Bash
+	Zsh               // the Z shell
+	Fish              // the friendly interactive shell
+)
+
+// Completion returns a script which registers completions of the options
+// in opts for the program prog in the shell sh.
+//
+// The generated scripts complete both the short- and long-forms of
+// options as well as the ‘--opt=value’ form of long-options that take
+// arguments.  The arguments of options are completed as filenames.  The
+// Help of each option is included in the completions of shells that
+// support it.
+//
+// If opts are invalid as described by [ValidateOpts], Completion returns
+// a [SpecError].  An error is also returned if sh is not one of the
+// shells listed above.
+func Completion(sh Shell, prog string, opts []LongOpt) (string, error) {
+	if err := ValidateOpts(opts); err != nil {
+		return "", err
+	}
+	switch sh {
+	case Bash:
+		return bashCompletion(prog, opts), nil
+	case Zsh:
+		return zshCompletion(prog, opts), nil
+	case Fish:
+		return fishCompletion(prog, opts), nil
+	}
+	return "", fmt.Errorf("opts: invalid shell %d", sh)
+}
+
+func bashCompletion(prog string, opts []LongOpt) string {
+	var words, reqs, optargs []string
+	for i, o := range opts {
+		s, l := "-"+string(o.Short), "--"+o.Long
+		if o.Short > 0 {
+			words = append(words, s)
+		}
+		if o.Long != "" {
+			switch o.Arg {
+			case None:
+				words = append(words, l)
+			case Required:
+				words = append(words, l+"=")
+			case Optional:
+				words = append(words, l, l+"=")
+			}
+		}
+		if o.Negatable && o.Long != "" {
+			words = append(words, "--no-"+o.Long)
+		}
+
+		switch o.Arg {
+		case Required:
+			if o.Short > 0 {
+				reqs = append(reqs, shQuote(s))
+			}
+			reqs = append(reqs, longPrefixes(opts, i)...)
+		case Optional:
+			optargs = append(optargs, longPrefixes(opts, i)...)
+		}
+	}
+
+	var sb strings.Builder
+	fn := "_" + shIdent(prog) + "_completion"
+	fmt.Fprintf(&sb, "%s()\n{\n", fn)
+	sb.WriteString("\tlocal cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} eq=\n")
+	sb.WriteString("\tif [[ $cur == = ]]; then\n")
+	sb.WriteString("\t\tcur= eq=1\n")
+	sb.WriteString("\telif [[ $prev == = ]]; then\n")
+	sb.WriteString("\t\tprev=${COMP_WORDS[COMP_CWORD-2]} eq=1\n")
+	sb.WriteString("\tfi\n\n")
+	sb.WriteString("\tcase $prev in\n")
+	if len(reqs) > 0 {
+		fmt.Fprintf(&sb, "\t%s)\n", strings.Join(reqs, "|"))
+		sb.WriteString("\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		sb.WriteString("\t\treturn\n\t\t;;\n")
+	}
+	if len(optargs) > 0 {
+		fmt.Fprintf(&sb, "\t%s)\n", strings.Join(optargs, "|"))
+		sb.WriteString("\t\tif [[ $eq ]]; then\n")
+		sb.WriteString("\t\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		sb.WriteString("\t\t\treturn\n\t\tfi\n\t\t;;\n")
+	}
+	sb.WriteString("\tesac\n\n")
+	sb.WriteString("\tcase $cur in\n")
+	sb.WriteString("\t-*)\n")
+	fmt.Fprintf(&sb, "\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n",
+		shQuote(strings.Join(words, " ")))
+	sb.WriteString("\t\t[[ $COMPREPLY == *= ]] && compopt -o nospace\n")
+	sb.WriteString("\t\t;;\n")
+	sb.WriteString("\t*)\n")
+	sb.WriteString("\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	sb.WriteString("\t\t;;\n")
+	sb.WriteString("\tesac\n")
+	sb.WriteString("}\n\n")
+	fmt.Fprintf(&sb, "complete -F %s %s\n", fn, shQuote(prog))
+	return sb.String()
+}
+
+// longPrefixes returns the quoted long-form of opts[i] and every
+// unambiguous abbreviation of it.
+func longPrefixes(opts []LongOpt, i int) []string {
+	var ps []string
+	l := opts[i].Long
+	for j := range l {
+		if j == 0 {
+			continue
+		}
+		if k, neg, _ := optStruct(opts, l[:j], true, false); k == i && !neg {
+			ps = append(ps, shQuote("--"+l[:j]))
+		}
+	}
+	if l != "" {
+		ps = append(ps, shQuote("--"+l))
+	}
+	return ps
+}
+
+func zshCompletion(prog string, opts []LongOpt) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#compdef %s\n\n", prog)
+	sb.WriteString("_arguments -s -S")
+	for _, o := range opts {
+		var forms []string
+		if o.Short > 0 {
+			s := "-" + string(o.Short)
+			switch o.Arg {
+			case Required:
+				s += "+"
+			case Optional:
+				s += "-"
+			}
+			forms = append(forms, s)
+		}
+		if o.Long != "" {
+			l := "--" + o.Long
+			switch o.Arg {
+			case Required:
+				l += "="
+			case Optional:
+				l += "=-"
+			}
+			forms = append(forms, l)
+		}
+
+		desc := ""
+		if o.Help != "" {
+			desc = "[" + zshEscape(firstLine(o.Help)) + "]"
+		}
+		switch o.Arg {
+		case Required:
+			desc += ":" + zshEscape(argName(o)) + ":_files"
+		case Optional:
+			desc += "::" + zshEscape(argName(o)) + ":_files"
+		}
+
+		sb.WriteString(" \\\n\t")
+		if len(forms) == 1 {
+			sb.WriteString(shQuote(forms[0] + desc))
+		} else {
+			excl := "(" + strings.TrimRight(forms[0], "+-") + " " +
+				strings.TrimRight(forms[1], "=-") + ")"
+			fmt.Fprintf(&sb, "%s{%s,%s}", shQuote(excl), shQuote(forms[0]),
+				shQuote(forms[1]))
+			if desc != "" {
+				sb.WriteString(shQuote(desc))
+			}
+		}
+		if o.Negatable && o.Long != "" {
+			fmt.Fprintf(&sb, " \\\n\t%s", shQuote("--no-"+o.Long))
+		}
+	}
+	sb.WriteString(" \\\n\t'*:file:_files'\n")
+	return sb.String()
+}
+
+func fishCompletion(prog string, opts []LongOpt) string {
+	var sb strings.Builder
+	for _, o := range opts {
+		fmt.Fprintf(&sb, "complete -c %s", shQuote(prog))
+		if o.Short > 0 {
+			fmt.Fprintf(&sb, " -s %s", shQuote(string(o.Short)))
+		}
+		if o.Long != "" {
+			fmt.Fprintf(&sb, " -l %s", shQuote(o.Long))
+		}
+		if o.Arg == Required {
+			sb.WriteString(" -r")
+		}
+		if o.Help != "" {
+			fmt.Fprintf(&sb, " -d %s", shQuote(firstLine(o.Help)))
+		}
+		sb.WriteByte('\n')
+		if o.Negatable && o.Long != "" {
+			fmt.Fprintf(&sb, "complete -c %s -l %s\n", shQuote(prog),
+				shQuote("no-"+o.Long))
+		}
+	}
+	return sb.String()
+}
+
+// shQuote quotes s for use as a single word in a POSIX shell or fish.
+func shQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '=' || r == '+' ||
+			r == '.' || r == '/' || r >= '0' && r <= '9' ||
+			r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r > 0x7F)
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shIdent returns s with every character that is not valid in a shell
+// function name replaced with an underscore.
+func shIdent(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' ||
+			r >= 'a' && r <= 'z' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// zshEscape escapes the characters in s which are special inside of an
+// _arguments specification.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	return s
+}
+
+// An ExpectKind represents the kind of argument that a [Parser] expects
+// to parse next.
+type ExpectKind int
+
+// These tokens describe the kind of argument expected by a [Parser].
+const (
+	ExpectOption  ExpectKind = iota // an option
+	ExpectValue                     // the argument of an option
+	ExpectOperand                   // a non-option argument
+)
+
+// Expect describes what a [Parser] expects to parse next, as returned by
+// [Parser.Complete].
+type Expect struct {
+	Kind ExpectKind
+
+	// Index is the index of the option whose argument is expected when
+	// Kind is ExpectValue, and -1 otherwise.
+	Index int
+
+	// Word is the portion of the word being completed that is to be
+	// replaced by a completion.  When completing the argument of
+	// ‘--opt=val’ or ‘-oval’, Word is ‘val’.
+	Word string
+
+	// Candidates holds the options that Word may be completed to when
+	// Kind is ExpectOption, spelled as they would be on the command-line.
+	Candidates []string
+}
+
+// Complete reports what p expects to parse in args[cword], given that
+// args[cword] may be incomplete.  This is intended for implementing
+// dynamic shell completions, where args are the words of the command-line
+// being completed and cword is the index of the word under the cursor.
+// Parsing errors in args before cword are ignored.
+//
+// When completing a long-option, the candidates are the long-options
+// that the word would be matched against by [Parser.Parse].  An
+// unambiguous abbreviation thus has a single candidate, and an ambiguous
+// one has all of the long-options it abbreviates as candidates.
+func (p *Parser) Complete(args []string, cword int) Expect {
+	if cword < 1 || cword >= len(args) {
+		return Expect{Kind: ExpectOperand, Index: -1}
+	}
+
+	it := p.Iter(args[:cword])
+	for {
+		_, ok, err := it.Next()
+		var e NoArgumentError
+		if errors.As(err, &e) {
+			k := -1
+			if e.Short != 0 {
+				k, _ = getModeRune(p.opts, e.Short)
+			} else {
+				k, _, _ = p.findLong(e.Long)
+			}
+			return Expect{Kind: ExpectValue, Index: k, Word: args[cword]}
+		}
+		if !ok && err == nil {
+			break
+		}
+	}
+
+	w := args[cword]
+	switch {
+	case it.s.stop, !strings.HasPrefix(w, "-"):
+		return Expect{Kind: ExpectOperand, Index: -1, Word: w}
+	case p.long && strings.HasPrefix(w, "--"):
+		return p.completeLong(w, "--")
+	case w == "-":
+		return Expect{Kind: ExpectOption, Index: -1, Word: w, Candidates: p.optionNames()}
+	case p.isLongOnly(w):
+		return p.completeLong(w, "-")
+	}
+
+	rs := []rune(w[1:])
+	for j, r := range rs {
+		k, ok := getModeRune(p.opts, r)
+		if ok && p.opts[k].Arg != None && j < len(rs)-1 {
+			return Expect{Kind: ExpectValue, Index: k, Word: string(rs[j+1:])}
+		}
+	}
+	return Expect{Kind: ExpectOption, Index: -1, Word: w, Candidates: []string{w}}
+}
+
+func (p *Parser) completeLong(w, dashes string) Expect {
+	n, v, eq := strings.Cut(w[len(dashes):], "=")
+	if eq {
+		k, _, _ := p.findLong(n)
+		return Expect{Kind: ExpectValue, Index: k, Word: v}
+	}
+
+	var cands []string
+	if k, neg, ls := p.findLong(n); ls != nil {
+		for _, l := range ls {
+			cands = append(cands, dashes+l)
+		}
+	} else if k != -1 {
+		l := p.opts[k].Long
+		if neg {
+			l = "no-" + l
+		}
+		cands = []string{dashes + l}
+	} else {
+		for _, o := range p.opts {
+			for _, l := range longForms(o) {
+				if hasPrefix(l, n, p.FoldCase) {
+					cands = append(cands, dashes+l)
+				}
+			}
+		}
+	}
+	return Expect{Kind: ExpectOption, Index: -1, Word: w, Candidates: cands}
+}
+
+// optionNames returns the spellings of every option in p.
+func (p *Parser) optionNames() []string {
+	var ns []string
+	for _, o := range p.opts {
+		if o.Short > 0 {
+			ns = append(ns, "-"+string(o.Short))
+		}
+		if p.long {
+			for _, l := range longForms(o) {
+				ns = append(ns, "--"+l)
+			}
+		}
+	}
+	return ns
+}
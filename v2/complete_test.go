@@ -0,0 +1,123 @@
+package opts
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompletionBash(t *testing.T) {
+	s, err := Completion(Bash, "my-tool", usageOpts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	for _, want := range []string{
+		"_my_tool_completion()\n",
+		"\t-a|--a|--ad|--add|-c)\n",
+		"\t--日|--日本|--日本語)\n",
+		"compgen -W '-a --add= -ß --sheiße -λ --λεωνίδας --no-short -日 --日本語 --日本語= -c'",
+		"complete -F _my_tool_completion my-tool\n",
+	} {
+		if !strings.Contains(s, want) {
+			die(t, "Completion(Bash)", want, s)
+		}
+	}
+}
+
+func TestCompletionZsh(t *testing.T) {
+	want := `#compdef my-tool
+
+_arguments -s -S \
+	'(-a --add)'{-a+,--add=}'[add an item]:arg:_files' \
+	'(-ß --sheiße)'{-ß,--sheiße}'[be rude]' \
+	'(-λ --λεωνίδας)'{-λ,--λεωνίδας} \
+	'--no-short[an option without a short-form]' \
+	'(-日 --日本語)'{-日-,--日本語=-}'[a wide option]::when:_files' \
+	'-c+:file:_files' \
+	'*:file:_files'
+`
+	if s, err := Completion(Zsh, "my-tool", usageOpts); err != nil || s != want {
+		die(t, "Completion(Zsh)", want, s)
+	}
+}
+
+func TestCompletionFish(t *testing.T) {
+	want := `complete -c my-tool -s a -l add -r -d 'add an item'
+complete -c my-tool -s ß -l sheiße -d 'be rude'
+complete -c my-tool -s λ -l λεωνίδας
+complete -c my-tool -l no-short -d 'an option without a short-form'
+complete -c my-tool -s 日 -l 日本語 -d 'a wide option'
+complete -c my-tool -s c -r
+`
+	if s, err := Completion(Fish, "my-tool", usageOpts); err != nil || s != want {
+		die(t, "Completion(Fish)", want, s)
+	}
+}
+
+func TestCompletionErrors(t *testing.T) {
+	for _, sh := range []Shell{Bash, Zsh, Fish} {
+		if _, err := Completion(sh, "x", []LongOpt{{Short: -1}}); !errors.As(err, new(SpecError)) {
+			die(t, "err", SpecError{}, err)
+		}
+	}
+	if _, err := Completion(Fish+1, "x", usageOpts); err == nil {
+		die(t, "err", "opts: invalid shell 3", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	p := NewLongParser([]LongOpt{
+		{Short: 'a', Long: "add", Arg: None},
+		{Short: 'd', Long: "delete", Arg: None},
+		{Short: 'D', Long: "defer", Arg: None},
+		{Short: 'c', Long: "change", Arg: Required},
+		{Short: 'Ħ', Long: "Ħaġrat", Arg: Optional},
+	})
+	p.Permute = true
+	tests := []struct {
+		args  []string
+		cword int
+		want  Expect
+	}{
+		{[]string{"foo", "--de"}, 1, Expect{ExpectOption, -1, "--de",
+			[]string{"--delete", "--defer"}}},
+		{[]string{"foo", "--a"}, 1, Expect{ExpectOption, -1, "--a",
+			[]string{"--add"}}},
+		{[]string{"foo", "--x"}, 1, Expect{ExpectOption, -1, "--x", nil}},
+		{[]string{"foo", "-a", "--ch", ""}, 3, Expect{ExpectValue, 3, "", nil}},
+		{[]string{"foo", "-ac", "ba"}, 2, Expect{ExpectValue, 3, "ba", nil}},
+		{[]string{"foo", "-acba"}, 1, Expect{ExpectValue, 3, "ba", nil}},
+		{[]string{"foo", "--Ħ=ba"}, 1, Expect{ExpectValue, 4, "ba", nil}},
+		{[]string{"foo", "--Ħ", "ba"}, 2, Expect{ExpectOperand, -1, "ba", nil}},
+		{[]string{"foo", "x", "--", "-"}, 3, Expect{ExpectOperand, -1, "-", nil}},
+		{[]string{"foo", "x", "-"}, 2, Expect{ExpectOption, -1, "-",
+			[]string{"-a", "--add", "-d", "--delete", "-D", "--defer",
+				"-c", "--change", "-Ħ", "--Ħaġrat"}}},
+	}
+	for _, tt := range tests {
+		if e := p.Complete(tt.args, tt.cword); !reflect.DeepEqual(e, tt.want) {
+			die(t, fmt.Sprintf("p.Complete(%q, %d)", tt.args, tt.cword), tt.want, e)
+		}
+	}
+}
+
+func TestCompleteNegatable(t *testing.T) {
+	p := NewLongParser(negatableOpts)
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"--no", []string{"--notify"}},
+		{"--no-", []string{"--no-color", "--no-compress"}},
+		{"--no-col", []string{"--no-color"}},
+		{"--no-c", []string{"--no-color", "--no-compress"}},
+	}
+	for _, tt := range tests {
+		e := p.Complete([]string{"foo", tt.word}, 1)
+		if !reflect.DeepEqual(e.Candidates, tt.want) {
+			die(t, fmt.Sprintf("p.Complete(%q).Candidates", tt.word), tt.want, e.Candidates)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package opts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A Config holds option values read from configuration files, keyed by
+// the long-forms of options.  A Config is used by setting the Config
+// field of a [Parser], which then falls back to the values of the Config
+// for options given neither on the command-line nor by the environment.
+// The values are interpreted as they would be if they were set by the
+// environment, with each value of a key producing a flag; see
+// [Parser.Parse] for details.
+type Config struct {
+	entries []configEntry
+}
+
+type configEntry struct {
+	key, value string
+	file       string
+	line       int
+}
+
+// LoadConfig reads the configuration files at paths in order and merges
+// them with [Config.Merge], such that the values in later files take
+// precedence over those in earlier ones.  Files that do not exist are
+// skipped.  Files with the extension ‘.json’ are parsed with [ParseJSON],
+// while all other files are parsed with [ParseINI].
+func LoadConfig(paths ...string) (*Config, error) {
+	c := new(Config)
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var d *Config
+		if filepath.Ext(path) == ".json" {
+			d, err = ParseJSON(f, path)
+		} else {
+			d, err = ParseINI(f, path)
+		}
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		c.Merge(d)
+	}
+	return c, nil
+}
+
+// ParseINI parses an INI-style configuration from r, using name as the
+// name of the file in errors and flags.  Each line holds either a
+// ‘key = value’ pair, a key on its own which is equivalent to
+// ‘key = true’, a ‘[section]’ header, or a comment beginning with ‘#’ or
+// ‘;’.  Values may be surrounded in double-quotes to preserve leading and
+// trailing whitespace.  Keys following a section header are prefixed by
+// the name of the section and a period, such that ‘add’ in the section
+// ‘remote’ becomes ‘remote.add’.  A key may be given multiple times.
+func ParseINI(r io.Reader, name string) (*Config, error) {
+	var c Config
+	var section string
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "", line[0] == '#', line[0] == ';':
+			continue
+		case line[0] == '[':
+			if line[len(line)-1] != ']' {
+				return nil, ConfigError{File: name, Line: n,
+					Err: errors.New("unterminated section header")}
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok {
+			v = "true"
+		}
+		if k == "" {
+			return nil, ConfigError{File: name, Line: n,
+				Err: errors.New("missing key")}
+		}
+		if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+			var err error
+			if v, err = strconv.Unquote(v); err != nil {
+				return nil, ConfigError{File: name, Line: n, Err: err}
+			}
+		}
+		if section != "" {
+			k = section + "." + k
+		}
+		c.entries = append(c.entries, configEntry{k, v, name, n})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ParseJSON parses a JSON configuration from r, using name as the name of
+// the file in errors and flags.  The configuration must be a JSON object
+// whose keys are the long-forms of options.  Values may be strings,
+// numbers, booleans, or arrays of such values, where each element of an
+// array is treated as a separate value of the key.  Nested objects are
+// flattened in the same manner as the sections of [ParseINI].
+func ParseJSON(r io.Reader, name string) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	line := func() int {
+		return bytes.Count(data[:d.InputOffset()], []byte{'\n'}) + 1
+	}
+	wrap := func(err error) error {
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			n := bytes.Count(data[:se.Offset], []byte{'\n'}) + 1
+			return ConfigError{File: name, Line: n, Err: err}
+		}
+		return ConfigError{File: name, Line: line(), Err: err}
+	}
+
+	var object func(prefix string) error
+	value := func(k string, t json.Token) error {
+		switch t := t.(type) {
+		case string:
+			c.entries = append(c.entries, configEntry{k, t, name, line()})
+		case json.Number:
+			c.entries = append(c.entries, configEntry{k, t.String(), name, line()})
+		case bool:
+			c.entries = append(c.entries, configEntry{k, strconv.FormatBool(t), name, line()})
+		default:
+			return fmt.Errorf("invalid value for key ‘%s’", k)
+		}
+		return nil
+	}
+	object = func(prefix string) error {
+		for d.More() {
+			t, err := d.Token()
+			if err != nil {
+				return err
+			}
+			k := prefix + t.(string)
+
+			if t, err = d.Token(); err != nil {
+				return err
+			}
+			switch t {
+			case json.Delim('{'):
+				if err := object(k + "."); err != nil {
+					return err
+				}
+			case json.Delim('['):
+				for d.More() {
+					t, err := d.Token()
+					if err != nil {
+						return err
+					}
+					if err := value(k, t); err != nil {
+						return err
+					}
+				}
+			default:
+				if err := value(k, t); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := d.Token(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t, err := d.Token()
+	if err != nil {
+		return nil, wrap(err)
+	}
+	if t != json.Delim('{') {
+		return nil, wrap(errors.New("configuration is not an object"))
+	}
+	if err := object(""); err != nil {
+		return nil, wrap(err)
+	}
+	if _, err := d.Token(); err != nil {
+		return nil, wrap(err)
+	}
+	return &c, nil
+}
+
+// Merge merges the values of d into c.  The values of every key set in d
+// replace the values of that key in c.
+func (c *Config) Merge(d *Config) {
+	keys := make(map[string]bool)
+	for _, e := range d.entries {
+		keys[e.key] = true
+	}
+	var es []configEntry
+	for _, e := range c.entries {
+		if !keys[e.key] {
+			es = append(es, e)
+		}
+	}
+	c.entries = append(es, d.entries...)
+}
+
+// Lookup returns the values of key in c, in the order they were read.
+func (c *Config) Lookup(key string) []string {
+	var vs []string
+	for _, e := range c.entries {
+		if e.key == key {
+			vs = append(vs, e.value)
+		}
+	}
+	return vs
+}
+
+// configFlags returns the flags set by p.Config for all the options
+// which do not appear in flags.
+func (p *Parser) configFlags(flags []Flag) ([]Flag, error) {
+	if p.Config == nil {
+		return nil, nil
+	}
+
+	given := make([]bool, len(p.opts))
+	for _, f := range flags {
+		if f.Index >= 0 {
+			given[f.Index] = true
+		}
+	}
+
+	var fs []Flag
+	var errs []error
+	for i, o := range p.opts {
+		if o.Long == "" || given[i] {
+			continue
+		}
+		for _, e := range p.Config.entries {
+			if e.key != o.Long {
+				continue
+			}
+
+			f := newFlag(p.opts, i, e.key, e.key+"="+e.value, -1)
+			f.Source = FromConfig
+			f.File, f.Line = e.file, e.line
+			if o.Arg == None {
+				b, ok := parseBool(e.value)
+				if !ok {
+					errs = append(errs, ConfigError{File: e.file, Line: e.line,
+						Err: fmt.Errorf("invalid boolean ‘%s’ for key ‘%s’", e.value, e.key)})
+				}
+				if !b && (!ok || !o.Negatable) {
+					continue
+				}
+				f.Negated = !b
+			} else {
+				f.Value = e.value
+			}
+			fs = append(fs, f)
+		}
+	}
+	return fs, errors.Join(errs...)
+}
+
+// Origin describes where f came from, for use in debugging.  For example
+// ‘command-line argument 3 (‘--add=x’)’, ‘environment variable
+// ‘MYTOOL_ADD’’, ‘config.ini:12’, or ‘args.rsp:2 (‘--add=x’)’ for flags
+// read from a response file.
+func (f Flag) Origin() string {
+	switch f.Source {
+	case FromEnv:
+		return fmt.Sprintf("environment variable ‘%s’", f.Name)
+	case FromConfig:
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	if f.File != "" {
+		return fmt.Sprintf("%s:%d (‘%s’)", f.File, f.Line, f.Raw)
+	}
+	return fmt.Sprintf("command-line argument %d (‘%s’)", f.Pos, f.Raw)
+}
+
+// Explain returns a report of where each of flags came from, with one
+// line per flag, for use in debugging.  Each line holds the long-form of
+// the option (or its short-form if it has none), its argument if it has
+// one, and the [Flag.Origin] of the flag.
+func Explain(flags []Flag) string {
+	var sb strings.Builder
+	for _, f := range flags {
+		if f.Long != "" {
+			sb.WriteString("--" + f.Long)
+		} else {
+			sb.WriteString("-" + string(f.Key))
+		}
+		if f.Value != "" {
+			sb.WriteString("=" + f.Value)
+		}
+		sb.WriteString("\tfrom " + f.Origin() + "\n")
+	}
+	return sb.String()
+}
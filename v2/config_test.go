@@ -0,0 +1,94 @@
+package opts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testINI = `# a comment
+verbose
+output = "  ini.txt  "
+include = a
+include = b
+
+[remote]
+add = origin
+`
+
+const testJSON = `{
+	"output": "json.txt",
+	"level": 3,
+	"verbose": false,
+	"remote": {"add": "upstream"}
+}`
+
+var configOpts = []LongOpt{
+	{Short: 'v', Long: "verbose", Arg: None},
+	{Short: 'o', Long: "output", Arg: Required},
+	{Short: 'I', Long: "include", Arg: Required},
+	{Short: 'l', Long: "level", Arg: Required},
+	{Short: 'c', Long: "colour", Arg: Optional},
+}
+
+func TestParseINI(t *testing.T) {
+	c, err := ParseINI(strings.NewReader(testINI), "test.ini")
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if vs := c.Lookup("output"); len(vs) != 1 || vs[0] != "  ini.txt  " {
+		die(t, "output", "  ini.txt  ", vs)
+	}
+	if vs := c.Lookup("remote.add"); len(vs) != 1 || vs[0] != "origin" {
+		die(t, "remote.add", "origin", vs)
+	}
+	if vs := c.Lookup("include"); len(vs) != 2 {
+		die(t, "include", []string{"a", "b"}, vs)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader(testINI), "test.ini")
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	js, err := ParseJSON(strings.NewReader(testJSON), "test.json")
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	ini.Merge(js)
+
+	p := NewLongParser(configOpts)
+	p.Config = ini
+	p.EnvPrefix = "T_"
+	p.LookupEnv = envLookup(map[string]string{"T_LEVEL": "7"})
+	flags, _, err := p.Parse([]string{"foo", "-Ix"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+
+	want := `--include=x	from command-line argument 1 (‘-Ix’)
+--level=7	from environment variable ‘T_LEVEL’
+--output=json.txt	from test.json:2
+`
+	if s := Explain(flags); s != want {
+		die(t, "Explain(flags)", want, s)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	_, err := ParseJSON(strings.NewReader("{\n\"a\": [{}]\n}"), "bad.json")
+	var e ConfigError
+	if !errors.As(err, &e) || e.File != "bad.json" || e.Line != 2 {
+		die(t, "err", "bad.json:2: invalid value for key ‘a’", err)
+	}
+
+	c, _ := ParseINI(strings.NewReader("verbose = maybe\n"), "bad.ini")
+	p := NewLongParser(configOpts)
+	p.Config = c
+	_, _, err = p.Parse([]string{"foo"})
+	want := "bad.ini:1: invalid boolean ‘maybe’ for key ‘verbose’"
+	if err == nil || err.Error() != want {
+		die(t, "err", want, err)
+	}
+}
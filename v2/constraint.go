@@ -0,0 +1,259 @@
+package opts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type constraintKind int
+
+const (
+	requireAll constraintKind = iota
+	exclusive
+	atLeastOne
+	requires
+	conflicts
+)
+
+// A Constraint describes a relationship between options that must hold
+// once they have been parsed, such as an option being required or two
+// options being mutually exclusive.  Constraints are created with
+// [RequireAll], [Exclusive], [AtLeastOne], [Requires], and [Conflicts],
+// and are checked with [Check] or by setting the Constraints field of a
+// [Parser].
+//
+// Constraints refer to options by their long-form, or by their short-form
+// as a string for options without a long-form.
+type Constraint struct {
+	kind  constraintKind
+	names []string
+}
+
+// RequireAll returns a [Constraint] requiring that each of the named
+// options is given.
+func RequireAll(names ...string) Constraint {
+	return Constraint{requireAll, names}
+}
+
+// Exclusive returns a [Constraint] requiring that at most one of the
+// named options is given.
+func Exclusive(names ...string) Constraint {
+	return Constraint{exclusive, names}
+}
+
+// AtLeastOne returns a [Constraint] requiring that at least one of the
+// named options is given.
+func AtLeastOne(names ...string) Constraint {
+	return Constraint{atLeastOne, names}
+}
+
+// Requires returns a [Constraint] requiring that if the option name is
+// given, then so are each of the options in deps.
+func Requires(name string, deps ...string) Constraint {
+	return Constraint{requires, append([]string{name}, deps...)}
+}
+
+// Conflicts returns a [Constraint] requiring that if the option name is
+// given, then none of the options in others are.  Unlike [Exclusive],
+// the options in others may be given together.
+func Conflicts(name string, others ...string) Constraint {
+	return Constraint{conflicts, append([]string{name}, others...)}
+}
+
+// Check checks that flags, as parsed according to opts, satisfy each of
+// the constraints in cs.  Flags are considered given regardless of their
+// Source.  If any constraint does not hold, the violations are returned
+// joined with [errors.Join], with each violation being one of a
+// [MissingOptionError], [ConflictError], or [DependencyError].  Options
+// that were given are named in errors as they were spelled by the user.
+// A negated flag cancels any earlier flags of the option it negates.
+//
+// If a constraint refers to an option not in opts, Check instead returns
+// a [SpecError] listing every such reference.
+func Check(flags []Flag, opts []LongOpt, cs []Constraint) error {
+	if err := checkConstraints(opts, cs); err != nil {
+		return err
+	}
+
+	given := make([]string, len(opts))
+	for _, f := range flags {
+		switch {
+		case f.Index < 0 || f.Index >= len(opts):
+		case f.Negated:
+			given[f.Index] = ""
+		case given[f.Index] == "":
+			given[f.Index] = f.Name
+		}
+	}
+
+	var errs []error
+	for _, c := range cs {
+		is, _ := c.indices(opts)
+		switch c.kind {
+		case requireAll:
+			for _, i := range is {
+				if given[i] == "" {
+					errs = append(errs, MissingOptionError{
+						Names: []string{optName(opts[i])},
+					})
+				}
+			}
+		case atLeastOne:
+			if !c.anyGiven(is, given) {
+				var ns []string
+				for _, i := range is {
+					ns = append(ns, optName(opts[i]))
+				}
+				errs = append(errs, MissingOptionError{Names: ns})
+			}
+		case exclusive:
+			var ns []string
+			for _, i := range is {
+				if given[i] != "" {
+					ns = append(ns, given[i])
+				}
+			}
+			if len(ns) > 1 {
+				errs = append(errs, ConflictError{Names: ns})
+			}
+		case conflicts:
+			if given[is[0]] == "" {
+				continue
+			}
+			for _, i := range is[1:] {
+				if given[i] != "" {
+					errs = append(errs, ConflictError{
+						Names: []string{given[is[0]], given[i]},
+					})
+				}
+			}
+		case requires:
+			if given[is[0]] == "" {
+				continue
+			}
+			var ns []string
+			for _, i := range is[1:] {
+				if given[i] == "" {
+					ns = append(ns, optName(opts[i]))
+				}
+			}
+			if len(ns) > 0 {
+				errs = append(errs, DependencyError{
+					Name:     given[is[0]],
+					Requires: ns,
+				})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// indices returns the indices in opts of the options named by c.  If c
+// refers to an option not in opts, ok is false.
+func (c Constraint) indices(opts []LongOpt) (is []int, ok bool) {
+	is = make([]int, len(c.names))
+	for j, n := range c.names {
+		is[j] = -1
+		for i, o := range opts {
+			if optKey(o) == n {
+				is[j] = i
+				break
+			}
+		}
+		if is[j] == -1 {
+			return nil, false
+		}
+	}
+	return is, true
+}
+
+// checkConstraints returns a [SpecError] listing every reference in cs
+// to an option not in opts, or nil if there are none.
+func checkConstraints(opts []LongOpt, cs []Constraint) error {
+	var problems []string
+	for _, c := range cs {
+		for _, n := range c.names {
+			if _, ok := (Constraint{names: []string{n}}).indices(opts); !ok {
+				problems = append(problems,
+					fmt.Sprintf("constraint refers to unknown option ‘%s’", n))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return SpecError{Problems: problems}
+	}
+	return nil
+}
+
+func (c Constraint) anyGiven(is []int, given []string) bool {
+	for _, i := range is {
+		if given[i] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// optKey returns the name by which constraints and [Accumulate] refer to
+// the option o, which is its long-form if it has one and its short-form
+// otherwise.
+func optKey(o LongOpt) string {
+	if o.Long != "" {
+		return o.Long
+	}
+	return string(o.Short)
+}
+
+// optName returns the canonical spelling of the option o, which is its
+// long-form if it has one and its short-form otherwise.
+func optName(o LongOpt) string {
+	if o.Long != "" {
+		return "--" + o.Long
+	}
+	return "-" + string(o.Short)
+}
+
+// constraintNotes returns notes describing the constraints in cs that
+// apply to each of the options in opts, for use in [Help].
+func constraintNotes(opts []LongOpt, cs []Constraint) [][]string {
+	notes := make([][]string, len(opts))
+	others := func(is []int, i int) string {
+		var ns []string
+		for _, j := range is {
+			if j != i {
+				ns = append(ns, optName(opts[j]))
+			}
+		}
+		return strings.Join(ns, ", ")
+	}
+
+	for _, c := range cs {
+		is, ok := c.indices(opts)
+		if !ok {
+			continue
+		}
+		switch c.kind {
+		case requireAll:
+			for _, i := range is {
+				notes[i] = append(notes[i], "required")
+			}
+		case atLeastOne:
+			for _, i := range is {
+				notes[i] = append(notes[i], "required unless "+others(is, i)+" given")
+			}
+		case exclusive:
+			for _, i := range is {
+				notes[i] = append(notes[i], "conflicts with "+others(is, i))
+			}
+		case conflicts:
+			notes[is[0]] = append(notes[is[0]], "conflicts with "+others(is, is[0]))
+			for _, i := range is[1:] {
+				notes[i] = append(notes[i], "conflicts with "+optName(opts[is[0]]))
+			}
+		case requires:
+			notes[is[0]] = append(notes[is[0]], "requires "+others(is, is[0]))
+		}
+	}
+	return notes
+}
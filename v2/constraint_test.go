@@ -0,0 +1,134 @@
+package opts
+
+import (
+	"reflect"
+	"testing"
+)
+
+var constraintOpts = []LongOpt{
+	{Short: 'j', Long: "json", Arg: None},
+	{Short: 'y', Long: "yaml", Arg: None},
+	{Short: 'o', Long: "output", Arg: Required, ArgName: "file"},
+	{Short: -1, Long: "key", Arg: Required},
+	{Short: -2, Long: "cert", Arg: Required},
+	{Short: 'v', Arg: None},
+}
+
+var constraints = []Constraint{
+	Exclusive("json", "yaml"),
+	RequireAll("output"),
+	Requires("key", "cert"),
+	Conflicts("v", "json"),
+}
+
+func assertCheck(t *testing.T, args []string, want []error) {
+	p := NewLongParser(constraintOpts)
+	p.Constraints = constraints
+	p.CollectErrors = true
+	_, _, err := p.Parse(args)
+	if errs := Errors(err); !reflect.DeepEqual(errs, want) {
+		die(t, "Errors(err)", want, errs)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assertCheck(t, []string{"foo", "-o", "x", "-j"}, nil)
+	assertCheck(t, []string{"foo", "--output=x", "--key=k", "--cert=c"}, nil)
+	assertCheck(t, []string{"foo"}, []error{
+		MissingOptionError{Names: []string{"--output"}},
+	})
+	assertCheck(t, []string{"foo", "-o", "x", "--js", "-y"}, []error{
+		ConflictError{Names: []string{"--js", "-y"}},
+	})
+	assertCheck(t, []string{"foo", "-vjo", "x"}, []error{
+		ConflictError{Names: []string{"-v", "-j"}},
+	})
+	assertCheck(t, []string{"foo", "--key", "k"}, []error{
+		MissingOptionError{Names: []string{"--output"}},
+		DependencyError{Name: "--key", Requires: []string{"--cert"}},
+	})
+}
+
+func TestCheckParseError(t *testing.T) {
+	p := NewLongParser(constraintOpts)
+	p.Constraints = constraints
+	_, _, err := p.Parse([]string{"foo", "-x"})
+	if _, ok := err.(BadOptionError); !ok {
+		die(t, "err", BadOptionError{Short: 'x', Pos: 1}, err)
+	}
+	_, _, err = p.Parse([]string{"foo", "-jy"})
+	if _, ok := err.(interface{ Unwrap() []error }); !ok {
+		die(t, "err", "joined errors", err)
+	}
+}
+
+func TestAtLeastOne(t *testing.T) {
+	cs := []Constraint{AtLeastOne("json", "yaml", "v")}
+	err := Check(nil, constraintOpts, cs)
+	want := "one of the options ‘--json’, ‘--yaml’, or ‘-v’ is required"
+	if err == nil || err.Error() != want {
+		die(t, "err.Error()", want, err)
+	}
+	flags, _, _ := GetLong([]string{"foo", "-v"}, constraintOpts)
+	if err := Check(flags, constraintOpts, cs); err != nil {
+		die(t, "err", nil, err)
+	}
+}
+
+func TestCheckUnknown(t *testing.T) {
+	cs := []Constraint{RequireAll("xml"), Requires("json", "yaml", "toml")}
+	want := SpecError{Problems: []string{
+		"constraint refers to unknown option ‘xml’",
+		"constraint refers to unknown option ‘toml’",
+	}}
+	if err := Check(nil, constraintOpts, cs); !reflect.DeepEqual(err, want) {
+		die(t, "err", want, err)
+	}
+
+	p := NewLongParser(constraintOpts)
+	p.Constraints = cs
+	if _, _, err := p.Parse([]string{"foo", "-j"}); !reflect.DeepEqual(err, want) {
+		die(t, "err", want, err)
+	}
+	if s := Synopsis(constraintOpts, cs...); s != Synopsis(constraintOpts) {
+		die(t, "Synopsis()", Synopsis(constraintOpts), s)
+	}
+	if s := Help(constraintOpts, cs...); s != Help(constraintOpts) {
+		die(t, "Help()", Help(constraintOpts), s)
+	}
+}
+
+func TestSynopsisConstraints(t *testing.T) {
+	want := "[-v] [-j | -y] -o file [--key=arg] [--cert=arg]"
+	if s := Synopsis(constraintOpts, constraints...); s != want {
+		die(t, "Synopsis()", want, s)
+	}
+	want = "(-j | -y | --key=arg) -o file [--cert=arg]"
+	cs := []Constraint{AtLeastOne("json", "yaml", "key"), RequireAll("output")}
+	if s := Synopsis(constraintOpts[:5], cs...); s != want {
+		die(t, "Synopsis()", want, s)
+	}
+}
+
+func TestHelpConstraints(t *testing.T) {
+	want := `  -j, --json         (conflicts with --yaml; conflicts with -v)
+  -y, --yaml         (conflicts with --json)
+  -o, --output=file  (required)
+      --key=arg      (requires --cert)
+      --cert=arg
+  -v                 (conflicts with --json)
+`
+	if s := Help(constraintOpts, constraints...); s != want {
+		die(t, "Help()", want, s)
+	}
+}
+
+func TestCheckNoArgs(t *testing.T) {
+	p := NewLongParser(constraintOpts)
+	p.Constraints = constraints
+	_, _, err := p.Parse(nil)
+	want := MissingOptionError{Names: []string{"--output"}}
+	if !reflect.DeepEqual(Errors(err), []error{want}) {
+		die(t, "err", want, err)
+	}
+}
@@ -0,0 +1,75 @@
+package opts
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// envName returns the name of the environment variable of the option o,
+// or the empty string if it has none.
+func (p *Parser) envName(o LongOpt) string {
+	switch {
+	case o.Env != "":
+		return o.Env
+	case p.EnvPrefix == "" || o.Long == "":
+		return ""
+	}
+	return p.EnvPrefix + strings.ToUpper(strings.ReplaceAll(o.Long, "-", "_"))
+}
+
+// envFlags returns the flags set by the environment variables of all the
+// options which do not appear in flags.
+func (p *Parser) envFlags(flags []Flag) ([]Flag, error) {
+	lookup := p.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	given := make([]bool, len(p.opts))
+	for _, f := range flags {
+		if f.Index >= 0 {
+			given[f.Index] = true
+		}
+	}
+
+	var fs []Flag
+	var errs []error
+	for i, o := range p.opts {
+		name := p.envName(o)
+		if name == "" || given[i] {
+			continue
+		}
+		v, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		f := newFlag(p.opts, i, name, name+"="+v, -1)
+		f.Source = FromEnv
+		if o.Arg == None {
+			b, ok := parseBool(v)
+			if !ok {
+				errs = append(errs, EnvError{Name: name, Value: v})
+			}
+			if !b && (!ok || !o.Negatable) {
+				continue
+			}
+			f.Negated = !b
+		} else {
+			f.Value = v
+		}
+		fs = append(fs, f)
+	}
+	return fs, errors.Join(errs...)
+}
+
+func parseBool(s string) (b, ok bool) {
+	switch strings.ToLower(s) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true, true
+	case "", "0", "f", "false", "n", "no", "off":
+		return false, true
+	}
+	return false, false
+}
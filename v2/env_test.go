@@ -0,0 +1,82 @@
+package opts
+
+import (
+	"errors"
+	"testing"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+}
+
+func TestEnv(t *testing.T) {
+	p := NewLongParser([]LongOpt{
+		{Short: 'q', Long: "quiet", Arg: None},
+		{Short: 'n', Long: "dry-run", Arg: None},
+		{Short: 'o', Long: "output", Arg: Required},
+		{Short: 'c', Long: "colour", Arg: Optional, Env: "COLOUR"},
+		{Short: 'v', Long: "verbose", Arg: None, Env: "VERBOSE"},
+	})
+	p.EnvPrefix = "MYTOOL_"
+	p.LookupEnv = envLookup(map[string]string{
+		"MYTOOL_QUIET":   "Yes",
+		"MYTOOL_DRY_RUN": "off",
+		"MYTOOL_OUTPUT":  "env.txt",
+		"MYTOOL_COLOUR":  "never",
+		"COLOUR":         "always",
+	})
+	flags, _, err := p.Parse([]string{"foo", "-o", "args.txt"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []Flag{
+		{Key: 'o', Long: "output", Index: 2, Value: "args.txt", Name: "-o",
+			Raw: "-o", Pos: 1},
+		{Key: 'q', Long: "quiet", Index: 0, Name: "MYTOOL_QUIET",
+			Raw: "MYTOOL_QUIET=Yes", Pos: -1, Source: FromEnv},
+		{Key: 'c', Long: "colour", Index: 3, Value: "always", Name: "COLOUR",
+			Raw: "COLOUR=always", Pos: -1, Source: FromEnv},
+	}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, f := range flags {
+		if f != want[i] {
+			die(t, "flags", want[i], f)
+		}
+	}
+}
+
+func TestEnvBadBool(t *testing.T) {
+	p := NewLongParser([]LongOpt{{Short: 'q', Long: "quiet", Arg: None}})
+	p.EnvPrefix = "MYTOOL_"
+	p.LookupEnv = envLookup(map[string]string{"MYTOOL_QUIET": "maybe"})
+	_, _, err := p.Parse([]string{"foo"})
+	want := EnvError{Name: "MYTOOL_QUIET", Value: "maybe"}
+	var e EnvError
+	if !errors.As(err, &e) || e != want {
+		die(t, "err", want, err)
+	}
+}
+
+func TestEnvNegatable(t *testing.T) {
+	p := NewLongParser([]LongOpt{
+		{Short: 'c', Long: "color", Arg: None, Negatable: true},
+		{Short: 'q', Long: "quiet", Arg: None},
+	})
+	p.EnvPrefix = "MYTOOL_"
+	p.LookupEnv = envLookup(map[string]string{
+		"MYTOOL_COLOR": "no",
+		"MYTOOL_QUIET": "no",
+	})
+	flags, _, err := p.Parse([]string{"foo"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 1 || flags[0].Key != 'c' || !flags[0].Negated {
+		die(t, "flags", []rune{'c'}, flags)
+	}
+}
@@ -1,31 +1,326 @@
 package opts
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Errors returns the individual errors that make up err.  If err was
+// created with [errors.Join], as is the case for the errors returned by a
+// [Parser] with CollectErrors set, its errors are returned recursively in
+// order.  Otherwise err itself is returned, unless it is nil.
+//
+// This is useful for reporting every error on its own line:
+//
+//	for _, e := range opts.Errors(err) {
+//		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], e)
+//	}
+func Errors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	u, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range u.Unwrap() {
+		errs = append(errs, Errors(e)...)
+	}
+	return errs
+}
 
 // A BadOptionError describes an option that the user attempted to pass
-// which the developer did not register.
+// which the developer did not register.  Exactly one of Short and Long is
+// set, depending on whether the unknown option was a short- or
+// long-option.
+//
+// If Long is set, Suggestions holds the long-forms of the registered
+// long-options that are most similar to Long, most similar first.  The
+// similarity of options is determined by their edit distance, with
+// transposed characters, differences in case, and the confusion of ‘-’
+// with ‘_’ being considered more similar than other typos.
 type BadOptionError struct {
-	r rune
-	s string
+	Short       rune     // the unknown short-option
+	Long        string   // the unknown long-option, without dashes
+	Pos         int      // the index of the offending argument
+	Suggestions []string // similar long-options, without dashes
+	OneDash     bool     // Long was given with a single dash
+	Plus        bool     // the option was given with ‘+’
 }
 
 func (e BadOptionError) Error() string {
-	if e.r != 0 {
-		return fmt.Sprintf("unknown option ‘-%c’", e.r)
+	if e.Short != 0 {
+		return fmt.Sprintf("unknown option ‘%s%c’", prefix(false, false, e.Plus), e.Short)
+	}
+	d := prefix(true, e.OneDash, e.Plus)
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown option ‘%s%s’", d, e.Long)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "unknown option ‘%s%s’; did you mean", d, e.Long)
+	for i, s := range e.Suggestions {
+		if i > 0 {
+			sb.WriteString(" or")
+		}
+		fmt.Fprintf(&sb, " ‘%s%s’", d, s)
+	}
+	sb.WriteByte('?')
+	return sb.String()
+}
+
+// An AmbiguousOptionError describes an abbreviated long-option that the
+// user attempted to pass which is a prefix of multiple registered
+// long-options.  The long-forms of the matching options are listed in
+// Candidates.
+type AmbiguousOptionError struct {
+	Long       string   // the ambiguous long-option, without dashes
+	Pos        int      // the index of the offending argument
+	Candidates []string // the long-options Long is a prefix of
+	OneDash    bool     // Long was given with a single dash
+	Plus       bool     // the option was given with ‘+’
+}
+
+func (e AmbiguousOptionError) Error() string {
+	d := prefix(true, e.OneDash, e.Plus)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "option ‘%s%s’ is ambiguous; possibilities:", d, e.Long)
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&sb, " ‘%s%s’", d, c)
 	}
-	return fmt.Sprintf("unknown option ‘--%s’", e.s)
+	return sb.String()
 }
 
 // A NoArgumentError describes an option that the user attempted to pass
-// without an argument, which required an argument.
+// without an argument, which required an argument.  Exactly one of Short
+// and Long is set, depending on whether the option was given in its
+// short- or long-form.  Long holds the long-option as it was spelled,
+// which may be an abbreviation.
 type NoArgumentError struct {
-	r rune
-	s string
+	Short   rune   // the short-option missing an argument
+	Long    string // the long-option missing an argument, without dashes
+	Pos     int    // the index of the offending argument
+	OneDash bool   // Long was given with a single dash
+	Plus    bool   // the option was given with ‘+’
 }
 
 func (e NoArgumentError) Error() string {
-	if e.r != 0 {
-		return fmt.Sprintf("expected argument for option ‘-%c’", e.r)
+	if e.Short != 0 {
+		return fmt.Sprintf("expected argument for option ‘%s%c’",
+			prefix(false, false, e.Plus), e.Short)
 	}
-	return fmt.Sprintf("expected argument for option ‘--%s’", e.s)
+	return fmt.Sprintf("expected argument for option ‘%s%s’",
+		prefix(true, e.OneDash, e.Plus), e.Long)
+}
+
+// An UnexpectedArgumentError describes a long-option that the user
+// attempted to pass with an argument (i.e. ‘--quiet=yes’), which does not
+// take an argument.
+type UnexpectedArgumentError struct {
+	Long    string // the long-option, without dashes
+	Value   string // the unexpected argument
+	Pos     int    // the index of the offending argument
+	OneDash bool   // Long was given with a single dash
+	Plus    bool   // the option was given with ‘+’
+}
+
+func (e UnexpectedArgumentError) Error() string {
+	return fmt.Sprintf("option ‘%s%s’ doesn’t allow an argument",
+		prefix(true, e.OneDash, e.Plus), e.Long)
+}
+
+// A DuplicateOptionError describes an option with the AtMostOnce policy
+// that the user gave more than once.  Exactly one of Short, Long, and Name
+// is set, depending on whether the repeated occurrence was given in its
+// short-, long-, or numeric form.
+type DuplicateOptionError struct {
+	Short   rune   // the repeated short-option
+	Long    string // the repeated long-option, without dashes
+	Name    string // the repeated numeric option as given, such as ‘-6’
+	Pos     int    // the index of the repeated occurrence
+	First   int    // the index of the first occurrence
+	OneDash bool   // Long was given with a single dash
+	Plus    bool   // the option was given with ‘+’
+}
+
+func (e DuplicateOptionError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("numeric option ‘%s’ given more than once", e.Name)
+	}
+	if e.Short != 0 {
+		return fmt.Sprintf("option ‘%s%c’ given more than once",
+			prefix(false, false, e.Plus), e.Short)
+	}
+	return fmt.Sprintf("option ‘%s%s’ given more than once",
+		prefix(true, e.OneDash, e.Plus), e.Long)
+}
+
+// prefix returns the prefix that an option was given with.
+func prefix(long, oneDash, plus bool) string {
+	switch {
+	case plus && long:
+		return "++"
+	case plus:
+		return "+"
+	case long && !oneDash:
+		return "--"
+	}
+	return "-"
+}
+
+// A BadCommandError describes a command that the user attempted to
+// invoke which the developer did not register.
+type BadCommandError struct {
+	Name string // the unknown command
+	Pos  int    // the index of the offending argument
+}
+
+func (e BadCommandError) Error() string {
+	return fmt.Sprintf("unknown command ‘%s’", e.Name)
+}
+
+// An AmbiguousCommandError describes an abbreviated command that the user
+// attempted to invoke which is a prefix of multiple registered commands.
+// The names of the matching commands are listed in Candidates.
+type AmbiguousCommandError struct {
+	Name       string   // the ambiguous command
+	Pos        int      // the index of the offending argument
+	Candidates []string // the commands Name is a prefix of
+}
+
+func (e AmbiguousCommandError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "command ‘%s’ is ambiguous; possibilities:", e.Name)
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&sb, " ‘%s’", c)
+	}
+	return sb.String()
+}
+
+// An EnvError describes an environment variable holding an invalid
+// boolean, for an option which takes no argument.
+type EnvError struct {
+	Name  string // the name of the environment variable
+	Value string // the value of the environment variable
+}
+
+func (e EnvError) Error() string {
+	return fmt.Sprintf("invalid boolean ‘%s’ for environment variable ‘%s’",
+		e.Value, e.Name)
+}
+
+// A ConfigError describes an error in a configuration file.
+type ConfigError struct {
+	File string // the name of the file
+	Line int    // the line of the file the error occurred on
+	Err  error  // the underlying error
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+}
+
+func (e ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// A MissingOptionError describes a required option that the user did
+// not give.  If Names holds multiple options, at least one of them was
+// required.
+type MissingOptionError struct {
+	Names []string // the missing options
+}
+
+func (e MissingOptionError) Error() string {
+	if len(e.Names) == 1 {
+		return fmt.Sprintf("option ‘%s’ is required", e.Names[0])
+	}
+	return "one of the options " + quoteList(e.Names, "or") + " is required"
+}
+
+// A ConflictError describes options that the user gave together which
+// cannot be used together.  Names holds the conflicting options as they
+// were spelled by the user.
+type ConflictError struct {
+	Names []string // the conflicting options
+}
+
+func (e ConflictError) Error() string {
+	return "options " + quoteList(e.Names, "and") + " are mutually exclusive"
+}
+
+// A DependencyError describes an option that the user gave without the
+// other options that it requires.
+type DependencyError struct {
+	Name     string   // the option as it was spelled by the user
+	Requires []string // the missing options
+}
+
+func (e DependencyError) Error() string {
+	return fmt.Sprintf("option ‘%s’ requires %s", e.Name, quoteList(e.Requires, "and"))
+}
+
+func quoteList(ss []string, conj string) string {
+	var sb strings.Builder
+	for i, s := range ss {
+		switch {
+		case i > 0 && i == len(ss)-1 && len(ss) > 2:
+			sb.WriteString(", " + conj + " ")
+		case i > 0 && i == len(ss)-1:
+			sb.WriteString(" " + conj + " ")
+		case i > 0:
+			sb.WriteString(", ")
+		}
+		sb.WriteString("‘" + s + "’")
+	}
+	return sb.String()
+}
+
+// A ResponseFileError describes an error in a response file, or an error
+// concerning an argument that was read from a response file.
+type ResponseFileError struct {
+	File string // the name of the response file
+	Line int    // the line of the file the error occurred on
+	Err  error  // the underlying error
+}
+
+func (e ResponseFileError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+}
+
+func (e ResponseFileError) Unwrap() error {
+	return e.Err
+}
+
+// A SpecError describes an invalid option specification, such as an
+// optstr listing an option more than once.  Problems holds a description
+// of every problem with the specification.
+type SpecError struct {
+	Problems []string
+}
+
+func (e SpecError) Error() string {
+	return "invalid option specification: " + strings.Join(e.Problems, "; ")
+}
+
+// A ValueError describes an option argument that could not be converted
+// to the type of the struct field it was to be stored in by [GetStruct],
+// or that is not a ‘key=value’ pair for an option with the Map policy.
+type ValueError struct {
+	Flag Flag  // the flag whose argument was invalid
+	Err  error // the conversion error
+}
+
+func (e ValueError) Error() string {
+	err := e.Err
+	if ne, ok := err.(*strconv.NumError); ok {
+		err = ne.Err
+	}
+	return fmt.Sprintf("invalid argument ‘%s’ for option ‘%s’: %s",
+		e.Flag.Value, e.Flag.Name, err)
+}
+
+func (e ValueError) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,59 @@
+package opts
+
+// An Iter parses command-line arguments one flag at a time, much like a
+// loop calling getopt(3).  An Iter is created with [Parser.Iter].
+//
+// Between calls to [Iter.Next] the caller is free to stop parsing, to
+// inspect the current position with [Iter.Optind], or to change the
+// options being parsed by modifying or replacing the Iter’s Parser.
+type Iter struct {
+	// Parser is the parser used to parse the next flag.
+	Parser *Parser
+
+	s state
+}
+
+// Iter returns a new [Iter] that parses args using p.  As with
+// [Parser.Parse], the first element of args is assumed to be the program
+// name and is skipped.
+func (p *Parser) Iter(args []string) *Iter {
+	return p.iterAt(args, min(1, len(args)))
+}
+
+// iterAt returns a new [Iter] that parses args using p, starting at
+// args[optind].
+func (p *Parser) iterAt(args []string, optind int) *Iter {
+	return &Iter{Parser: p, s: state{args: args, optind: optind}}
+}
+
+// Next parses and returns the next flag.  Once there are no more flags to
+// parse, ok is false.  If a flag fails to parse the error is returned in
+// err, and parsing may be resumed by calling Next again.
+func (it *Iter) Next() (f Flag, ok bool, err error) {
+	return it.Parser.next(&it.s)
+}
+
+// Optind returns the index in args of the next argument to be parsed.  If
+// parsing stopped in the middle of a cluster of short-options, Optind
+// returns the index of the cluster.  Once Next has returned false, Optind
+// returns the index of the first argument that was not parsed, which is
+// the argument following ‘--’ if parsing stopped at a ‘--’.
+func (it *Iter) Optind() int {
+	return it.s.optind
+}
+
+// Rest returns the arguments that have not been parsed as flags.  Once
+// Next has returned false, these are the non-option arguments.  If called
+// before then, Rest also returns the arguments that have yet to be
+// parsed, including the unparsed remainder of a cluster of short-options
+// as its own argument.  For example, if parsing is stopped after ‘-a’ in
+// ‘-abc’, the argument ‘-bc’ is included in Rest.
+func (it *Iter) Rest() []string {
+	rest := it.s.rest[:len(it.s.rest):len(it.s.rest)]
+	i := it.s.optind
+	if it.s.j < len(it.s.rs) {
+		rest = append(rest, prefix(false, false, it.s.plus)+string(it.s.rs[it.s.j:]))
+		i++
+	}
+	return append(rest, it.s.args[i:]...)
+}
@@ -0,0 +1,74 @@
+package opts
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestIterStop(t *testing.T) {
+	it := NewParser("ae").Iter([]string{"foo", "-a", "-ea", "x", "-a"})
+	for {
+		f, ok, err := it.Next()
+		if err != nil {
+			die(t, "err", nil, err)
+		}
+		if !ok {
+			t.Fatal("Expected to stop at ‘-e’")
+		}
+		if f.Key == 'e' {
+			break
+		}
+	}
+	if it.Optind() != 2 {
+		die(t, "it.Optind()", 2, it.Optind())
+	}
+	if want := []string{"-a", "x", "-a"}; !slices.Equal(it.Rest(), want) {
+		die(t, "it.Rest()", want, it.Rest())
+	}
+}
+
+func TestIterChangeSpec(t *testing.T) {
+	it := NewParser("e").Iter([]string{"foo", "-e", "-x", "-y", "bar"})
+	if f, _, err := it.Next(); err != nil || f.Key != 'e' {
+		die(t, "f.Key", 'e', f.Key)
+	}
+	it.Parser = NewParser("xy")
+	var keys []rune
+	for {
+		f, ok, err := it.Next()
+		if err != nil {
+			die(t, "err", nil, err)
+		}
+		if !ok {
+			break
+		}
+		keys = append(keys, f.Key)
+	}
+	if !slices.Equal(keys, []rune{'x', 'y'}) {
+		die(t, "keys", []rune{'x', 'y'}, keys)
+	}
+	if it.Optind() != 4 {
+		die(t, "it.Optind()", 4, it.Optind())
+	}
+	if want := []string{"bar"}; !slices.Equal(it.Rest(), want) {
+		die(t, "it.Rest()", want, it.Rest())
+	}
+}
+
+func TestIterResume(t *testing.T) {
+	it := NewParser("a").Iter([]string{"foo", "-aXa"})
+	want := []error{nil, BadOptionError{Short: 'X', Pos: 1}, nil}
+	for i, ew := range want {
+		_, ok, err := it.Next()
+		if !reflect.DeepEqual(err, ew) {
+			die(t, "err", ew, err)
+		}
+		if !ok && err == nil {
+			t.Fatalf("Expected %d flags but got %d", len(want), i)
+		}
+	}
+	if _, ok, _ := it.Next(); ok {
+		die(t, "ok", false, ok)
+	}
+}
@@ -10,11 +10,20 @@
 // This package properly supports unicode flags, but also does not
 // support a leading ‘:’ in the [Get] function’s option string; all
 // user-facing I/O is delegrated to the caller.
+//
+// Parsing errors are reported using the error types defined by this
+// package, which carry the details of the offending option.  Callers that
+// wish to inspect an error should use [errors.As]:
+//
+//	var e opts.BadOptionError
+//	if errors.As(err, &e) && e.Long != "" {
+//		fmt.Fprintf(os.Stderr, "unknown long-option %s\n", e.Long)
+//	}
 package opts
 
 import (
-	"slices"
 	"strings"
+	"unicode/utf8"
 )
 
 // ArgMode represents whether or not a long-option takes an argument.
@@ -28,16 +37,69 @@ const (
 	Optional                // long opt optionally takes an argument
 )
 
+// Operand is the Key of flags that represent non-option arguments.  Such
+// flags are only returned when parsing in-order, in which case the flags
+// Value holds the non-option argument.
+const Operand rune = 1
+
 // Flag represents a parsed command-line flag.  Key corresponds to the
 // rune that was passed on the command-line, and Value corresponds to the
 // flags argument if one was provided.  In the case of long-options Key
 // will map to the corresponding short-code, even if a long-option was
 // used.
+//
+// Long and Index identify the option that was matched.  Index is the
+// position of the matched option in the [LongOpt] slice given to
+// [GetLong], or the position of the option amongst the options listed in
+// the opt-string given to [Get].  This allows for distinguishing between
+// multiple long-options that share the same negative short-code.
+//
+// The remaining fields describe how the flag was spelled by the user.
+// Name holds the flag exactly as it was written, without any argument
+// (i.e. ‘-a’, ‘--ad’, or ‘--add’), while Raw holds the entire command-line
+// argument the flag was found in and Pos holds the index of Raw in the
+// arguments that were parsed.  When Inline is true the flags argument was
+// attached to the flag (i.e. ‘-abar’ or ‘--add=bar’) as opposed to being
+// taken from the following command-line argument.
+//
+// Plus is true when the flag was given with the prefix ‘+’ or ‘++’ in
+// place of ‘-’ or ‘--’, as is allowed by a [Parser] with PlusOptions set.
+//
+// Negated is true when the negated form of a Negatable option was given
+// (i.e. ‘--no-color’), in which case the flag identifies the option that
+// was negated and has no Value.
+//
+// Flags need not come from the command-line; Source describes where a
+// flag came from.  Flags read from a file also record the name of the
+// file and the line the flag was read from in File and Line.
 type Flag struct {
 	Key   rune   // the flag that was passed
 	Value string // the flags argument
+	Long  string // the long-form of the flag, if any
+	Index int    // the index of the matched option
+
+	Name    string // the flag as it was spelled
+	Raw     string // the argument containing the flag
+	Pos     int    // the index of Raw in the arguments
+	IsLong  bool   // the long-form of the flag was used
+	Inline  bool   // the flags argument was attached to the flag
+	Negated bool   // the negated form of the option was given
+	Plus    bool   // the flag was given with ‘+’ instead of ‘-’
+	Source  Source // where the flag came from
+	File    string // the file the flag was read from
+	Line    int    // the line of File the flag was read from
 }
 
+// Source represents where a [Flag] came from.
+type Source int
+
+// These tokens describe where a [Flag] came from.
+const (
+	FromArgs   Source = iota // the flag was given on the command-line
+	FromEnv                  // the flag was set by an environment variable
+	FromConfig               // the flag was set by a configuration file
+)
+
 // LongOpt represents a long-option to attempt to parse.  All long
 // options have a short-hand form represented by Short and a long-form
 // represented by Long.  Arg is used to represent whether or not the
@@ -45,10 +107,43 @@ type Flag struct {
 //
 // In the case that you want to parse a long-option which doesn’t have a
 // short-hand form, you can set Short to a negative integer.
+//
+// If Env is set, the environment variable it names is consulted when the
+// option is not given on the command-line.  See [Parser.Parse] for
+// details.
+//
+// Policy describes how repeated occurrences of the option are treated;
+// see [Policy] for details.  Sep is the separator that the arguments of
+// options with the Split policy are split on.
+//
+// If Negatable is set, the option may also be given in the negated form
+// ‘--no-’ followed by its long-form, such as ‘--no-color’ for ‘--color’.
+// The negated form never takes an argument and is reported as a flag for
+// the same option with Negated set.
+//
+// If Numeric is set, the option is also given by a run of the ASCII
+// digits ‘0’ to ‘9’ appearing in a cluster of short-options, such as
+// ‘-15’ in ‘head -15’.  The digits are reported as a single flag for the
+// option with its Value holding the number, while digits that are
+// themselves the short-form of an option are parsed as such.  At most
+// one option should be Numeric.
+//
+// The remaining fields are optional and are only used when generating
+// usage messages with [Synopsis] and [Help].
 type LongOpt struct {
 	Short rune
 	Long  string
 	Arg   ArgMode
+	Env   string
+
+	Negatable bool   // the option may be negated with ‘--no-’
+	Policy    Policy // how repeated occurrences are treated
+	Sep       string // the separator of Split arguments, ‘,’ by default
+	Numeric   bool   // the option may be given as ‘-NUM’
+
+	Help    string // a description of the option
+	ArgName string // the name of the options argument, ‘arg’ by default
+	Default string // the default value of the options argument
 }
 
 // Get parses the command-line arguments in args according to optstr.
@@ -63,53 +158,31 @@ type LongOpt struct {
 // optstr == "a::ßλ:" will search for ‘-a’ with an optional argument,
 // ‘-ß’ with no argument, and ‘-λ’ with a required argument.
 //
+// A ‘#’ in optstr declares the option ‘-#’ to be Numeric as described
+// in [LongOpt], such that given optstr == "#n" the arguments ‘-15n’
+// parse as the flag ‘#’ with the Value ‘15’ followed by the flag ‘n’.
+//
+// Parsing stops at the first non-option argument.  If optstr begins with
+// a ‘-’ however, non-option arguments are instead returned in-order as
+// flags with the Key [Operand] and parsing continues until ‘--’ is
+// reached.  See [Parser] for GNU-style argument permutation.
+//
 // A successful parse returns the flags in the flags slice and a slice of
 // the remaining non-option arguments in rest.  In the case of failure,
-// err will be one of [BadOptionError] or [NoArgumentError].
+// err will be one of [BadOptionError] or [NoArgumentError], or in the
+// case of [GetLong], [AmbiguousOptionError], [UnexpectedArgumentError],
+// or one of the errors resulting from the Policy of an option.
+// Parsing stops at the first error, in which case no flags are returned.
+// To instead parse past errors and report all of them at once, use a
+// [Parser] with CollectErrors set.
+//
+// Unlike a [Parser], Get does not reject an invalid optstr; options
+// listed more than once shadow each other, and superfluous colons are
+// ignored.  Use [CompileOptstr] to validate an optstr.
 func Get(args []string, optstr string) (flags []Flag, rest []string, err error) {
-	if len(args) == 0 {
-		return
-	}
-
-	optrs := []rune(optstr)
-	var i int
-	for i = 1; i < len(args); i++ {
-		arg := args[i]
-		if len(arg) == 0 || arg == "-" || arg[0] != '-' {
-			break
-		} else if arg == "--" {
-			i++
-			break
-		}
-
-		rs := []rune(arg[1:])
-		for j, r := range rs {
-			k := slices.Index(optrs, r)
-			if k == -1 {
-				return nil, nil, BadOptionError{r: r}
-			}
-
-			var s string
-			switch am := colonsToArgMode(optrs[k+1:]); {
-			case am != None && j < len(rs)-1:
-				s = string(rs[j+1:])
-			case am == Required:
-				i++
-				if i >= len(args) {
-					return nil, nil, NoArgumentError{r: r}
-				}
-				s = args[i]
-			default:
-				flags = append(flags, Flag{Key: r})
-				continue
-			}
-
-			flags = append(flags, Flag{r, s})
-			break
-		}
-	}
-
-	return flags, args[i:], nil
+	p := NewParser(optstr)
+	p.spec = nil
+	return p.Parse(args)
 }
 
 // GetLong parses the command-line arguments in args according to opts.
@@ -126,101 +199,111 @@ func Get(args []string, optstr string) (flags []Flag, rest []string, err error)
 //	}
 //
 // The options ‘--a’ and ‘--ad’ will parse as ‘--add’.  The option ‘--de’
-// will not parse however as it is ambiguous.
+// will not parse however as it is ambiguous, and will result in an
+// [AmbiguousOptionError].  A long-option given in full is never
+// ambiguous, even if it is also a prefix of another long-option.
+//
+// The negated forms of Negatable options may be abbreviated in the same
+// manner, but only once the abbreviation includes all of ‘--no-’.  Given
+// the Negatable options ‘--color’ and ‘--compress’, ‘--no-col’ parses as
+// ‘--no-color’ while ‘--no-c’ is ambiguous and ‘--no’ matches neither.
+//
+// Passing an argument to a long-option that takes no argument, such as
+// ‘--add=bar’ given the above opts, results in an
+// [UnexpectedArgumentError].  To silently discard such arguments instead,
+// use a [Parser] with Lenient set.
+//
+// As with [Get], GetLong does not reject invalid options; use
+// [ValidateOpts] to validate them.
 func GetLong(args []string, opts []LongOpt) (flags []Flag, rest []string, err error) {
-	if len(args) == 0 {
-		return
-	}
-
-	var i int
-	for i = 1; i < len(args); i++ {
-		arg := args[i]
-		if len(arg) == 0 || arg == "-" || arg[0] != '-' {
-			break
-		} else if arg == "--" {
-			i++
-			break
-		}
-
-		if strings.HasPrefix(arg, "--") {
-			arg = arg[2:]
+	p := NewLongParser(opts)
+	p.spec = nil
+	return p.Parse(args)
+}
 
-			n := arg
-			j := strings.IndexByte(n, '=')
-			if j != -1 {
-				n = arg[:j]
-			}
+// GetLongOnly parses the command-line arguments in args according to opts
+// in the manner of getopt_long_only(3).
+//
+// This function is identical to [GetLong] except that long-options may
+// also be given with a single dash, such that ‘-add’ and ‘-ad’ parse as
+// ‘--add’ given the opts in the documentation of [GetLong].  See the
+// LongOnly field of [Parser] for the details of how arguments beginning
+// with a single dash are disambiguated.  Like GetLong, GetLongOnly does
+// not reject invalid options.
+func GetLongOnly(args []string, opts []LongOpt) (flags []Flag, rest []string, err error) {
+	p := NewLongParser(opts)
+	p.spec = nil
+	p.LongOnly = true
+	return p.Parse(args)
+}
 
-			var s string
-			o, ok := optStruct(opts, n)
+func getModeRune(os []LongOpt, r rune) (int, bool) {
+	for i, o := range os {
+		if o.Short == r {
+			return i, true
+		}
+	}
+	return -1, false
+}
 
+// optStruct returns the index of the long-option in os that s is either
+// an exact match for or, if abbrev is true, an unambiguous prefix of.
+// The negated forms of Negatable options are matched as well, in which
+// case neg is true; s only abbreviates a negated form if it begins with
+// all of ‘no-’.  If s is ambiguous, all the forms it is a prefix of are
+// returned in cands.  If fold is true, s is matched case-insensitively.
+func optStruct(os []LongOpt, s string, abbrev, fold bool) (i int, neg bool, cands []string) {
+	i = -1
+	for j, o := range os {
+		for k, l := range longForms(o) {
+			n := k == 1
 			switch {
-			case !ok:
-				return nil, nil, BadOptionError{s: n}
-			case o.Arg != None && j != -1:
-				s = arg[j+1:]
-			case o.Arg == Required:
-				i++
-				if i >= len(args) {
-					return nil, nil, NoArgumentError{s: n}
-				}
-				s = args[i]
+			case l == s, fold && strings.EqualFold(l, s):
+				return j, n, nil
+			case !abbrev, n && !hasPrefix(s, "no-", fold), !hasPrefix(l, s, fold):
+				continue
 			}
-
-			flags = append(flags, Flag{Key: o.Short, Value: s})
-		} else {
-			rs := []rune(arg[1:])
-			for j, r := range rs {
-				var s string
-
-				switch am, ok := getModeRune(opts, r); {
-				case !ok:
-					return nil, nil, BadOptionError{r: r}
-				case am != None && j < len(rs)-1:
-					s = string(rs[j+1:])
-				case am == Required:
-					i++
-					if i >= len(args) {
-						return nil, nil, NoArgumentError{r: r}
-					}
-					s = args[i]
-				default:
-					flags = append(flags, Flag{Key: r})
-					continue
-				}
-
-				flags = append(flags, Flag{r, s})
-				break
+			if i == -1 {
+				i, neg = j, n
 			}
+			cands = append(cands, l)
 		}
 	}
-
-	return flags, args[i:], nil
+	if len(cands) > 1 {
+		return -1, false, cands
+	}
+	return i, neg, nil
 }
 
-func getModeRune(os []LongOpt, r rune) (ArgMode, bool) {
-	for _, o := range os {
-		if o.Short == r {
-			return o.Arg, true
-		}
+// longForms returns the long-form of o followed by its negated form if
+// o is Negatable.
+func longForms(o LongOpt) []string {
+	switch {
+	case o.Long == "":
+		return nil
+	case o.Negatable:
+		return []string{o.Long, "no-" + o.Long}
 	}
-	return 0, false
+	return []string{o.Long}
 }
 
-func optStruct(os []LongOpt, s string) (LongOpt, bool) {
-	i := -1
-	for j, o := range os {
-		if strings.HasPrefix(o.Long, s) {
-			if i != -1 {
-				return LongOpt{}, false
-			}
-			i = j
-		}
+// hasPrefix is like strings.HasPrefix, but optionally matches using
+// unicode case-folding.
+func hasPrefix(s, prefix string, fold bool) bool {
+	if !fold {
+		return strings.HasPrefix(s, prefix)
 	}
-	if i == -1 {
-		return LongOpt{}, false
+	for _, r := range prefix {
+		if s == "" {
+			return false
+		}
+		q, n := utf8.DecodeRuneInString(s)
+		if !strings.EqualFold(string(q), string(r)) {
+			return false
+		}
+		s = s[n:]
 	}
-	return os[i], true
+	return true
 }
 
 func colonsToArgMode(rs []rune) ArgMode {
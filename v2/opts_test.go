@@ -1,7 +1,9 @@
 package opts
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -13,7 +15,7 @@ func die(t *testing.T, name string, want, got any) {
 
 func assertGet(t *testing.T, args []string, fw, rw int, ew error) []Flag {
 	flags, rest, err := Get(args, "abλc:dßĦ::")
-	if err != ew {
+	if !reflect.DeepEqual(err, ew) {
 		die(t, "err", ew, err)
 	}
 	if len(rest) != rw {
@@ -37,7 +39,7 @@ func TestNoFlag(t *testing.T) {
 
 func TestCNoArg(t *testing.T) {
 	args := []string{"foo", "-c"}
-	assertGet(t, args, 0, 0, NoArgumentError{r: 'c'})
+	assertGet(t, args, 0, 0, NoArgumentError{Short: 'c', Pos: 1})
 }
 
 func TestCWithArg(t *testing.T) {
@@ -202,12 +204,12 @@ func TestΛAsArgToC(t *testing.T) {
 
 func TestInvalidFlag(t *testing.T) {
 	args := []string{"foo", "-X"}
-	assertGet(t, args, 0, 0, BadOptionError{r: 'X'})
+	assertGet(t, args, 0, 0, BadOptionError{Short: 'X', Pos: 1})
 }
 
 func TestInvalidFlagWithArg(t *testing.T) {
 	args := []string{"foo", "-X", "bar"}
-	assertGet(t, args, 0, 0, BadOptionError{r: 'X'})
+	assertGet(t, args, 0, 0, BadOptionError{Short: 'X', Pos: 1})
 }
 
 func TestAAfterArg(t *testing.T) {
@@ -273,7 +275,7 @@ func assertGetLong(t *testing.T, args []string, fw, rw int, ew error) []Flag {
 		{Short: 'Ħ', Long: "Ħaġrat", Arg: Optional},
 	}
 	flags, rest, err := GetLong(args, opts)
-	if err != ew {
+	if !reflect.DeepEqual(err, ew) {
 		die(t, "err", ew, err)
 	}
 	if len(rest) != rw {
@@ -297,7 +299,7 @@ func TestNoFlagL(t *testing.T) {
 
 func TestChangeNoArg(t *testing.T) {
 	args := []string{"foo", "--change"}
-	assertGetLong(t, args, 0, 0, NoArgumentError{s: "change"})
+	assertGetLong(t, args, 0, 0, NoArgumentError{Long: "change", Pos: 1})
 }
 
 func TestChangeArgEqual(t *testing.T) {
@@ -335,7 +337,11 @@ func TestChangeArgSpaceShort(t *testing.T) {
 
 func TestChangeArgSpaceShortFail(t *testing.T) {
 	args := []string{"foo", "--c", "bar"}
-	assertGetLong(t, args, 0, 0, BadOptionError{s: "c"})
+	assertGetLong(t, args, 0, 0, AmbiguousOptionError{
+		Long:       "c",
+		Pos:        1,
+		Candidates: []string{"change", "count"},
+	})
 }
 
 func TestĦaġratNoArg(t *testing.T) {
@@ -364,3 +370,232 @@ func TestĦaġratArgSpace(t *testing.T) {
 		die(t, "flags[0].Value", "", flags[0].Value)
 	}
 }
+
+func TestΛĦIndex(t *testing.T) {
+	args := []string{"foo", "-λĦbar"}
+	flags := assertGet(t, args, 2, 0, nil)
+	if flags[0].Index != 2 {
+		die(t, "flags[0].Index", 2, flags[0].Index)
+	}
+	if flags[1].Index != 6 {
+		die(t, "flags[1].Index", 6, flags[1].Index)
+	}
+}
+
+func TestLongOnlyIndex(t *testing.T) {
+	opts := []LongOpt{
+		{Short: 'a', Long: "add", Arg: None},
+		{Short: -1, Long: "verbose-json", Arg: None},
+		{Short: -1, Long: "dry-run", Arg: None},
+	}
+	args := []string{"foo", "--dry-run", "--verbose-json", "-a"}
+	flags, _, err := GetLong(args, opts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []Flag{
+		{Key: -1, Long: "dry-run", Index: 2},
+		{Key: -1, Long: "verbose-json", Index: 1},
+		{Key: 'a', Long: "add", Index: 0},
+	}
+	for i := range want {
+		want[i].Name = args[i+1]
+		want[i].Raw = args[i+1]
+		want[i].Pos = i + 1
+		want[i].IsLong = want[i].Key == -1
+	}
+	for i, f := range flags {
+		if f != want[i] {
+			die(t, fmt.Sprintf("flags[%d]", i), want[i], f)
+		}
+	}
+}
+
+func TestΛĦProvenance(t *testing.T) {
+	args := []string{"foo", "-a", "-λĦbar", "-c", "baz"}
+	flags := assertGet(t, args, 4, 0, nil)
+	want := []Flag{
+		{Key: 'a', Index: 0, Name: "-a", Raw: "-a", Pos: 1},
+		{Key: 'λ', Index: 2, Name: "-λ", Raw: "-λĦbar", Pos: 2},
+		{Key: 'Ħ', Index: 6, Value: "bar", Name: "-Ħ", Raw: "-λĦbar", Pos: 2, Inline: true},
+		{Key: 'c', Index: 3, Value: "baz", Name: "-c", Raw: "-c", Pos: 3},
+	}
+	for i, f := range flags {
+		if f != want[i] {
+			die(t, fmt.Sprintf("flags[%d]", i), want[i], f)
+		}
+	}
+}
+
+func TestChangeProvenance(t *testing.T) {
+	args := []string{"foo", "--ch=bar", "-c", "baz", "--change", "qux"}
+	flags := assertGetLong(t, args, 3, 0, nil)
+	want := []Flag{
+		{Key: 'c', Long: "change", Index: 3, Value: "bar", Name: "--ch", Raw: "--ch=bar", Pos: 1, IsLong: true, Inline: true},
+		{Key: 'c', Long: "change", Index: 3, Value: "baz", Name: "-c", Raw: "-c", Pos: 2},
+		{Key: 'c', Long: "change", Index: 3, Value: "qux", Name: "--change", Raw: "--change", Pos: 4, IsLong: true},
+	}
+	for i, f := range flags {
+		if f != want[i] {
+			die(t, fmt.Sprintf("flags[%d]", i), want[i], f)
+		}
+	}
+}
+
+func TestErrorsAs(t *testing.T) {
+	args := []string{"foo", "-a", "--add", "-bcλ", "--lorem"}
+	_, _, err := GetLong(args, []LongOpt{
+		{Short: 'a', Long: "add", Arg: None},
+		{Short: 'b', Long: "back", Arg: None},
+	})
+	var e BadOptionError
+	if !errors.As(err, &e) {
+		die(t, "err", BadOptionError{}, err)
+	}
+	if e.Short != 'c' || e.Pos != 3 {
+		die(t, "err", BadOptionError{Short: 'c', Pos: 3}, e)
+	}
+}
+
+func TestAmbiguousMessage(t *testing.T) {
+	args := []string{"foo", "--de"}
+	_, _, err := GetLong(args, []LongOpt{
+		{Short: 'a', Long: "add", Arg: None},
+		{Short: 'd', Long: "delete", Arg: None},
+		{Short: 'D', Long: "defer", Arg: None},
+	})
+	want := "option ‘--de’ is ambiguous; possibilities: ‘--delete’ ‘--defer’"
+	if err == nil || err.Error() != want {
+		die(t, "err", want, err)
+	}
+}
+
+func TestExactMatchWins(t *testing.T) {
+	opts := []LongOpt{
+		{Short: 'A', Long: "address", Arg: Required},
+		{Short: 'a', Long: "add", Arg: None},
+	}
+	flags, _, err := GetLong([]string{"foo", "--add", "--addr=x"}, opts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 2 || flags[0].Key != 'a' || flags[1].Key != 'A' {
+		die(t, "flags", []rune{'a', 'A'}, flags)
+	}
+	_, _, err = GetLong([]string{"foo", "--ad"}, opts)
+	var e AmbiguousOptionError
+	if !errors.As(err, &e) {
+		die(t, "err", AmbiguousOptionError{}, err)
+	}
+}
+
+func TestAddArgEqual(t *testing.T) {
+	args := []string{"foo", "--ad=bar"}
+	assertGetLong(t, args, 0, 0, UnexpectedArgumentError{
+		Long:  "ad",
+		Value: "bar",
+		Pos:   1,
+	})
+}
+
+func TestSuggestions(t *testing.T) {
+	opts := []LongOpt{
+		{Short: 'v', Long: "verbose", Arg: None},
+		{Short: 'V', Long: "version", Arg: None},
+		{Short: 'n', Long: "dry_run", Arg: None},
+		{Short: 'λ', Long: "λεωνίδας", Arg: None},
+		{Short: 'f', Long: "format", Arg: None},
+		{Short: 'F', Long: "formal", Arg: None},
+	}
+	tests := []struct {
+		arg  string
+		want []string
+	}{
+		{"--verbsoe", []string{"verbose"}},
+		{"--VERBOSE", []string{"verbose"}},
+		{"--versoin", []string{"version"}},
+		{"--formax", []string{"format", "formal"}},
+		{"--dry-run", []string{"dry_run"}},
+		{"--Λεωνιδας", []string{"λεωνίδας"}},
+		{"--lorem-ipsum", nil},
+	}
+	for _, tt := range tests {
+		_, _, err := GetLong([]string{"foo", tt.arg}, opts)
+		var e BadOptionError
+		if !errors.As(err, &e) || !reflect.DeepEqual(e.Suggestions, tt.want) {
+			die(t, "err.Suggestions", tt.want, err)
+		}
+	}
+
+	_, _, err := GetLong([]string{"foo", "--formax"}, opts)
+	want := "unknown option ‘--formax’; did you mean ‘--format’ or ‘--formal’?"
+	if err.Error() != want {
+		die(t, "err", want, err)
+	}
+}
+
+// NEGATABLE OPTS
+
+var negatableOpts = []LongOpt{
+	{Short: 'c', Long: "color", Arg: None, Negatable: true},
+	{Short: 'z', Long: "compress", Arg: Optional, Negatable: true},
+	{Short: 'n', Long: "notify", Arg: None},
+}
+
+func TestNegatable(t *testing.T) {
+	args := []string{"foo", "--no-color", "--no-com", "--color", "--no"}
+	flags, _, err := GetLong(args, negatableOpts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []struct {
+		key     rune
+		name    string
+		negated bool
+	}{
+		{'c', "--no-color", true},
+		{'z', "--no-com", true},
+		{'c', "--color", false},
+		{'n', "--no", false},
+	}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, w := range want {
+		if f := flags[i]; f.Key != w.key || f.Name != w.name || f.Negated != w.negated {
+			die(t, fmt.Sprintf("flags[%d]", i), w, f)
+		}
+	}
+}
+
+func TestNegatableAmbiguous(t *testing.T) {
+	_, _, err := GetLong([]string{"foo", "--no-c"}, negatableOpts)
+	want := AmbiguousOptionError{
+		Long:       "no-c",
+		Pos:        1,
+		Candidates: []string{"no-color", "no-compress"},
+	}
+	if !reflect.DeepEqual(err, want) {
+		die(t, "err", want, err)
+	}
+}
+
+func TestNegatableArg(t *testing.T) {
+	args := []string{"foo", "--no-compress", "bar", "--compress=9"}
+	flags, rest, err := GetLong(args, negatableOpts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 1 || !flags[0].Negated || flags[0].Value != "" {
+		die(t, "flags", []rune{'z'}, flags)
+	}
+	if len(rest) != 2 {
+		die(t, "rest", []string{"bar", "--compress=9"}, rest)
+	}
+
+	_, _, err = GetLong([]string{"foo", "--no-compress=9"}, negatableOpts)
+	want := UnexpectedArgumentError{Long: "no-compress", Value: "9", Pos: 1}
+	if err != want {
+		die(t, "err", want, err)
+	}
+}
@@ -0,0 +1,498 @@
+package opts
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Parser parses command-line arguments according to a set of options.
+// A Parser is created with [NewParser] or [NewLongParser] and can then be
+// configured by setting its exported fields before calling
+// [Parser.Parse].  The zero-value Parser recognizes no options.
+type Parser struct {
+	// Permute enables GNU-style argument permutation.  When true, options
+	// are parsed from anywhere in the arguments instead of parsing
+	// stopping at the first non-option argument.  The non-option
+	// arguments are returned in rest in the order that they were given.
+	// Regardless of Permute, parsing always stops at ‘--’.
+	Permute bool
+
+	// InOrder causes non-option arguments to be returned in-order as
+	// flags with the Key [Operand] instead of stopping the parse.  InOrder
+	// takes precedence over Permute.
+	InOrder bool
+
+	// Lenient causes arguments given to long-options which do not take
+	// an argument (i.e. ‘--quiet=yes’) to be silently discarded instead
+	// of resulting in an [UnexpectedArgumentError].
+	Lenient bool
+
+	// Abbrev enables the matching of unambiguous abbreviations of
+	// long-options, such that ‘--ad’ matches ‘--add’.  Abbrev is set by
+	// [NewLongParser].
+	Abbrev bool
+
+	// FoldCase causes long-options to be matched case-insensitively using
+	// unicode case-folding, such that ‘--ĦAĠRAT’ matches ‘--ħaġrat’.
+	// Short-options are always matched case-sensitively.
+	FoldCase bool
+
+	// DashOption causes a lone ‘-’ to be parsed as the short-option ‘-’
+	// instead of as a non-option argument.
+	DashOption bool
+
+	// LongOnly enables the long-only mode of getopt_long_only(3), causing
+	// arguments starting with a single ‘-’ to be matched against the
+	// long-options before being parsed as a cluster of short-options,
+	// such that ‘-add’ matches ‘--add’.  As with glibc, a lone short-option
+	// such as ‘-a’ is always parsed as such, and an argument only falls
+	// back to being parsed as a cluster of short-options if it matches no
+	// long-option and its first character is a valid short-option.  An
+	// ambiguous abbreviation is an error.  Errors concerning long-options
+	// given with a single ‘-’ have their OneDash field set.
+	LongOnly bool
+
+	// PlusOptions causes arguments starting with a ‘+’ to be parsed as
+	// clusters of short-options in the same manner as those starting with
+	// a ‘-’, and arguments starting with ‘++’ to be parsed as long-options
+	// when parsing long-options.  Flags given this way have their Plus
+	// field set, as do the errors concerning them.  This is useful for
+	// set(1)-style toggles where ‘-x’ enables a setting and ‘+x’ disables
+	// it.  A lone ‘+’ remains a non-option argument.
+	PlusOptions bool
+
+	// CollectErrors causes parsing to continue past errors instead of
+	// stopping at the first one.  All the flags that were successfully
+	// parsed and the non-option arguments are returned along with all
+	// the errors encountered, joined with [errors.Join].  Unknown
+	// short-options are skipped over while the rest of their cluster is
+	// parsed, and unknown or ambiguous long-options are skipped entirely.
+	// The individual errors may be retrieved with [Errors].
+	CollectErrors bool
+
+	// EnvPrefix enables environment variable fallbacks for all
+	// long-options that do not specify an Env.  The name of the
+	// environment variable of such an option is EnvPrefix followed by the
+	// options long-form in uppercase, with hyphens replaced by
+	// underscores; given an EnvPrefix of ‘MYTOOL_’ the option ‘--dry-run’
+	// is read from ‘MYTOOL_DRY_RUN’.
+	EnvPrefix string
+
+	// LookupEnv is used to retrieve the values of environment variables.
+	// If nil, [os.LookupEnv] is used.
+	LookupEnv func(key string) (string, bool)
+
+	// Config holds option values to fall back to when an option is given
+	// neither on the command-line nor by its environment variable.
+	Config *Config
+
+	// ResponseFiles enables the expansion of response files.  Each
+	// argument of the form ‘@file’ is replaced by the words read from the
+	// named file before parsing, and response files may themselves name
+	// further response files.  The words of a response file are separated
+	// by whitespace and may be quoted and escaped as in a POSIX shell,
+	// while a ‘#’ at the start of a word begins a comment extending to the
+	// end of the line.  Relative paths are resolved relative to the
+	// current directory, and a response file which includes itself is an
+	// error.  Arguments after ‘--’ are never expanded.
+	//
+	// The Pos of flags and errors refers to the expanded arguments.  Flags
+	// read from a response file have their File and Line set, and errors
+	// concerning them are wrapped in a [ResponseFileError].
+	ResponseFiles bool
+
+	// Constraints holds constraints that the parsed flags must satisfy,
+	// which are checked with [Check] once parsing has finished.
+	Constraints []Constraint
+
+	opts  []LongOpt
+	spec  error // the problems with opts, if any
+	long  bool
+	posix bool
+}
+
+// NewParser returns a new [Parser] that parses short-options according
+// to optstr.  The optstr is interpreted as it would be by [Get].
+// Additionally, if optstr begins with a ‘+’ then argument permutation is
+// disabled regardless of the value of the Parser’s Permute field.
+//
+// If optstr is invalid as described by [CompileOptstr], the Parser
+// refuses to parse and instead returns a [SpecError].
+func NewParser(optstr string) *Parser {
+	var p Parser
+	switch {
+	case strings.HasPrefix(optstr, "-"):
+		p.InOrder = true
+		optstr = optstr[1:]
+	case strings.HasPrefix(optstr, "+"):
+		p.posix = true
+		optstr = optstr[1:]
+	}
+	p.opts, p.spec = CompileOptstr(optstr)
+	return &p
+}
+
+// NewLongParser returns a new [Parser] that parses both short- and
+// long-options according to opts.  The opts are interpreted as they would
+// be by [GetLong].  If opts are invalid as described by [ValidateOpts],
+// the Parser refuses to parse and instead returns a [SpecError].
+func NewLongParser(opts []LongOpt) *Parser {
+	return &Parser{opts: opts, spec: ValidateOpts(opts), long: true, Abbrev: true}
+}
+
+// Parse parses the command-line arguments in args.  As with [Get] and
+// [GetLong], the first element of args is assumed to be the program name
+// and is skipped.  An empty args is not special: the environment,
+// p.Config, and p.Constraints are still consulted as described below.
+//
+// Once the command-line arguments have been parsed, the LastWins,
+// FirstWins, and AtMostOnce policies of the options are applied to the
+// resulting flags as described by [Policy].  Then the environment
+// variables of the options that were not given on the command-line are
+// consulted in the order that the options were specified.  For each such
+// variable that is set, a flag is appended to flags with its Source set
+// to [FromEnv], its Name set to the name of the variable, and its Pos set
+// to -1.  The value of the variable is taken as the options argument.
+// For options that take no argument, the variable must instead hold a
+// boolean: one of ‘1’, ‘t’, ‘true’, ‘y’, ‘yes’, or ‘on’ to give the option,
+// or one of ‘0’, ‘f’, ‘false’, ‘n’, ‘no’, ‘off’, or the empty string to
+// not give it, ignoring case.  Any other value results in an [EnvError].
+// A false boolean gives the negated form of a Negatable option.
+//
+// Finally, the values in p.Config of the options that were given neither
+// on the command-line nor by the environment are consulted in the same
+// manner, resulting in flags with their Source set to [FromConfig].  See
+// [Config] for details.
+//
+// If all of the above succeeds, the flags are checked against
+// p.Constraints.
+func (p *Parser) Parse(args []string) (flags []Flag, rest []string, err error) {
+	if p.spec != nil {
+		return nil, nil, p.spec
+	}
+	if err := checkConstraints(p.opts, p.Constraints); err != nil {
+		return nil, nil, err
+	}
+	var errs []error
+	var origins []origin
+	if p.ResponseFiles {
+		var err error
+		args, origins, err = expandResponseFiles(args)
+		if err != nil {
+			if !p.CollectErrors {
+				return nil, nil, err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	it := p.Iter(args)
+	for {
+		f, ok, err := it.Next()
+		err = locate(err, origins)
+		if err != nil {
+			if !p.CollectErrors {
+				return nil, nil, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			break
+		}
+		if origins != nil {
+			f.File, f.Line = origins[f.Pos].file, origins[f.Pos].line
+		}
+		flags = append(flags, f)
+	}
+
+	flags, err = p.applyPolicies(flags)
+	err = locate(err, origins)
+	if err != nil {
+		if !p.CollectErrors {
+			return nil, nil, err
+		}
+		errs = append(errs, err)
+	}
+
+	for _, fn := range [...]func([]Flag) ([]Flag, error){p.envFlags, p.configFlags} {
+		fs, err := fn(flags)
+		if err != nil {
+			if !p.CollectErrors {
+				return nil, nil, err
+			}
+			errs = append(errs, err)
+		}
+		flags = append(flags, fs...)
+	}
+
+	if err := Check(flags, p.opts, p.Constraints); err != nil {
+		if !p.CollectErrors {
+			return nil, nil, err
+		}
+		errs = append(errs, err)
+	}
+
+	return flags, it.Rest(), errors.Join(errs...)
+}
+
+// state holds the progress of a parse between calls to [Parser.next].
+type state struct {
+	args   []string
+	optind int      // the index of the next argument to parse
+	rs     []rune   // the short-option cluster being parsed
+	j      int      // the index of the next rune in rs
+	rest   []string // the non-option arguments skipped when permuting
+	plus   bool     // the cluster in rs was given with ‘+’
+	done   bool     // there are no more flags to parse
+	stop   bool     // parsing stopped before the end of args
+	dashes bool     // parsing stopped at ‘--’
+}
+
+func (p *Parser) permute() bool {
+	return p.Permute && !p.posix
+}
+
+// next parses and returns the next flag in s.  If there are no more
+// flags to parse, ok is false and the non-option arguments are s.rest
+// followed by s.args[s.optind:].
+func (p *Parser) next(s *state) (f Flag, ok bool, err error) {
+	if s.done {
+		return Flag{}, false, nil
+	}
+	if p.spec != nil {
+		s.done = true
+		return Flag{}, false, p.spec
+	}
+	if s.j < len(s.rs) {
+		return p.nextShort(s)
+	}
+
+	for ; s.optind < len(s.args); s.optind++ {
+		arg := s.args[s.optind]
+		switch {
+		case arg == "--":
+			s.optind++
+			s.done, s.stop, s.dashes = true, true, true
+			return Flag{}, false, nil
+		case arg == "-" && p.DashOption:
+			s.rs, s.j, s.plus = []rune(arg), 0, false
+			return p.nextShort(s)
+		case len(arg) > 1 && arg[0] == '-':
+			if p.long && strings.HasPrefix(arg, "--") {
+				return p.nextLong(s, "--")
+			}
+			if p.isLongOnly(arg) {
+				return p.nextLong(s, "-")
+			}
+			s.rs, s.j, s.plus = []rune(arg[1:]), 0, false
+			return p.nextShort(s)
+		case len(arg) > 1 && arg[0] == '+' && p.PlusOptions:
+			if p.long && len(arg) > 2 && arg[1] == '+' {
+				return p.nextLong(s, "++")
+			}
+			s.rs, s.j, s.plus = []rune(arg[1:]), 0, true
+			return p.nextShort(s)
+		case p.InOrder:
+			s.optind++
+			return Flag{
+				Key:   Operand,
+				Value: arg,
+				Index: -1,
+				Raw:   arg,
+				Pos:   s.optind - 1,
+			}, true, nil
+		case p.permute():
+			s.rest = append(s.rest, arg)
+		default:
+			s.done, s.stop = true, true
+			return Flag{}, false, nil
+		}
+	}
+
+	s.done = true
+	return Flag{}, false, nil
+}
+
+// isLongOnly reports whether the single-dash argument arg is to be parsed
+// as a long-option.  This follows the rules of glibc’s getopt_long_only(3).
+func (p *Parser) isLongOnly(arg string) bool {
+	if !p.long || !p.LongOnly {
+		return false
+	}
+	n, _, _ := strings.Cut(arg[1:], "=")
+	r, size := utf8.DecodeRuneInString(arg[1:])
+	_, short := getModeRune(p.opts, r)
+	short = short || p.numericOpt(r) != -1
+	switch {
+	case n == "", short && size == len(arg)-1:
+		return false
+	case !short:
+		return true
+	}
+	k, _, cands := p.findLong(n)
+	return k != -1 || cands != nil
+}
+
+// findLong returns the index of the long-option matched by n, as
+// described by optStruct.
+func (p *Parser) findLong(n string) (int, bool, []string) {
+	return optStruct(p.opts, n, p.Abbrev, p.FoldCase)
+}
+
+func (p *Parser) nextLong(s *state, dashes string) (Flag, bool, error) {
+	arg := s.args[s.optind][len(dashes):]
+	s.optind++
+
+	n := arg
+	j := strings.IndexByte(n, '=')
+	if j != -1 {
+		n = arg[:j]
+	}
+
+	oneDash, plus := dashes == "-", dashes == "++"
+	k, neg, cands := p.findLong(n)
+	switch {
+	case cands != nil:
+		return Flag{}, false, AmbiguousOptionError{
+			Long:       n,
+			Pos:        s.optind - 1,
+			Candidates: cands,
+			OneDash:    oneDash,
+			Plus:       plus,
+		}
+	case k == -1:
+		return Flag{}, false, BadOptionError{
+			Long:        n,
+			Pos:         s.optind - 1,
+			Suggestions: suggest(p.opts, n),
+			OneDash:     oneDash,
+			Plus:        plus,
+		}
+	}
+
+	f := newFlag(p.opts, k, dashes+n, s.args[s.optind-1], s.optind-1)
+	f.IsLong = true
+	f.Negated = neg
+	f.Plus = plus
+
+	switch o := p.opts[k]; {
+	case (o.Arg == None || neg) && j != -1 && !p.Lenient:
+		return Flag{}, false, UnexpectedArgumentError{
+			Long:    n,
+			Value:   arg[j+1:],
+			Pos:     s.optind - 1,
+			OneDash: oneDash,
+			Plus:    plus,
+		}
+	case neg:
+	case o.Arg != None && j != -1:
+		f.Value = arg[j+1:]
+		f.Inline = true
+	case o.Arg == Required:
+		if s.optind >= len(s.args) {
+			return Flag{}, false, NoArgumentError{
+				Long:    n,
+				Pos:     s.optind - 1,
+				OneDash: oneDash,
+				Plus:    plus,
+			}
+		}
+		f.Value = s.args[s.optind]
+		s.optind++
+	}
+
+	return f, true, nil
+}
+
+// numericOpt returns the index of the Numeric option that the digit r
+// belongs to, or -1 if it has none.
+func (p *Parser) numericOpt(r rune) int {
+	if r < '0' || r > '9' {
+		return -1
+	}
+	if _, ok := getModeRune(p.opts, r); ok {
+		return -1
+	}
+	for i, o := range p.opts {
+		if o.Numeric {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *Parser) nextShort(s *state) (Flag, bool, error) {
+	if k := p.numericOpt(s.rs[s.j]); k != -1 {
+		return p.nextNumber(s, k)
+	}
+
+	r := s.rs[s.j]
+	s.j++
+
+	pos := s.optind
+	if s.j == len(s.rs) {
+		s.optind++
+	}
+
+	k, ok := getModeRune(p.opts, r)
+	if !ok {
+		return Flag{}, false, BadOptionError{Short: r, Pos: pos, Plus: s.plus}
+	}
+
+	name := prefix(false, false, s.plus) + string(r)
+	if s.args[pos] == "-" {
+		name = "-"
+	}
+	f := newFlag(p.opts, k, name, s.args[pos], pos)
+	f.Plus = s.plus
+
+	switch am := p.opts[k].Arg; {
+	case am != None && s.j < len(s.rs):
+		f.Value = string(s.rs[s.j:])
+		f.Inline = true
+		s.j = len(s.rs)
+		s.optind++
+	case am == Required:
+		if s.optind >= len(s.args) {
+			return Flag{}, false, NoArgumentError{Short: r, Pos: pos, Plus: s.plus}
+		}
+		f.Value = s.args[s.optind]
+		s.optind++
+	}
+
+	return f, true, nil
+}
+
+// nextNumber parses the run of digits in s.rs starting at s.j as a flag
+// of the Numeric option k.
+func (p *Parser) nextNumber(s *state, k int) (Flag, bool, error) {
+	i := s.j
+	for s.j < len(s.rs) && s.rs[s.j] >= '0' && s.rs[s.j] <= '9' {
+		s.j++
+	}
+
+	pos := s.optind
+	if s.j == len(s.rs) {
+		s.optind++
+	}
+
+	n := string(s.rs[i:s.j])
+	f := newFlag(p.opts, k, prefix(false, false, s.plus)+n, s.args[pos], pos)
+	f.Value = n
+	f.Inline = true
+	f.Plus = s.plus
+	return f, true, nil
+}
+
+func newFlag(os []LongOpt, k int, name, raw string, pos int) Flag {
+	return Flag{
+		Key:   os[k].Short,
+		Long:  os[k].Long,
+		Index: k,
+		Name:  name,
+		Raw:   raw,
+		Pos:   pos,
+	}
+}
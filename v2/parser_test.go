@@ -0,0 +1,387 @@
+package opts
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestPermute(t *testing.T) {
+	p := NewParser("ac:")
+	p.Permute = true
+	args := []string{"foo", "x", "-a", "y", "-c", "z", "w", "--", "-a"}
+	flags, rest, err := p.Parse(args)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 2 {
+		die(t, "flags", 2, flags)
+	}
+	if flags[0].Key != 'a' || flags[0].Pos != 2 {
+		die(t, "flags[0]", Flag{Key: 'a', Pos: 2}, flags[0])
+	}
+	if flags[1].Key != 'c' || flags[1].Value != "z" {
+		die(t, "flags[1]", Flag{Key: 'c', Value: "z"}, flags[1])
+	}
+	if want := []string{"x", "y", "w", "-a"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+}
+
+func TestPermuteLong(t *testing.T) {
+	p := NewLongParser([]LongOpt{
+		{Short: 'v', Long: "verbose", Arg: None},
+		{Short: 'o', Long: "output", Arg: Required},
+	})
+	p.Permute = true
+	args := []string{"foo", "file1", "--verb", "file2", "--output", "-", "-"}
+	flags, rest, err := p.Parse(args)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 2 {
+		die(t, "flags", 2, flags)
+	}
+	if flags[1].Key != 'o' || flags[1].Value != "-" {
+		die(t, "flags[1]", Flag{Key: 'o', Value: "-"}, flags[1])
+	}
+	if want := []string{"file1", "file2", "-"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+}
+
+func TestPermutePosix(t *testing.T) {
+	p := NewParser("+a")
+	p.Permute = true
+	args := []string{"foo", "-a", "x", "-a"}
+	flags, rest, err := p.Parse(args)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 1 {
+		die(t, "flags", 1, flags)
+	}
+	if want := []string{"x", "-a"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+}
+
+func TestInOrder(t *testing.T) {
+	args := []string{"foo", "x", "-a", "y", "--", "-a", "z"}
+	flags, rest, err := Get(args, "-a")
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []rune{Operand, 'a', Operand}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, r := range want {
+		if flags[i].Key != r {
+			die(t, fmt.Sprintf("flags[%d].Key", i), r, flags[i].Key)
+		}
+	}
+	if flags[2].Value != "y" || flags[2].Pos != 3 {
+		die(t, "flags[2]", Flag{Key: Operand, Value: "y", Pos: 3}, flags[2])
+	}
+	if want := []string{"-a", "z"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+}
+
+func TestLenient(t *testing.T) {
+	p := NewLongParser([]LongOpt{{Short: 'q', Long: "quiet", Arg: None}})
+	p.Lenient = true
+	flags, _, err := p.Parse([]string{"foo", "--quiet=yes"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != 1 || flags[0].Value != "" {
+		die(t, "flags", []Flag{{Key: 'q'}}, flags)
+	}
+}
+
+func assertParse(t *testing.T, p *Parser, args []string, want []rune) []Flag {
+	flags, _, err := p.Parse(args)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, r := range want {
+		if flags[i].Key != r {
+			die(t, fmt.Sprintf("flags[%d].Key", i), r, flags[i].Key)
+		}
+	}
+	return flags
+}
+
+var parserOpts = []LongOpt{
+	{Short: 'a', Long: "add", Arg: None},
+	{Short: 'd', Long: "delete", Arg: None},
+	{Short: 'Ħ', Long: "ħaġrat", Arg: Required},
+	{Short: '-', Long: "", Arg: None},
+}
+
+func TestNoAbbrev(t *testing.T) {
+	p := NewLongParser(parserOpts)
+	p.Abbrev = false
+	assertParse(t, p, []string{"foo", "--add"}, []rune{'a'})
+	_, _, err := p.Parse([]string{"foo", "--ad"})
+	want := BadOptionError{Long: "ad", Pos: 1, Suggestions: []string{"add"}}
+	if !reflect.DeepEqual(err, want) {
+		die(t, "err", want, err)
+	}
+}
+
+func TestFoldCase(t *testing.T) {
+	p := NewLongParser(parserOpts)
+	p.FoldCase = true
+	flags := assertParse(t, p, []string{"foo", "--ĦAĠ=x", "--DELETE"},
+		[]rune{'Ħ', 'd'})
+	if flags[0].Value != "x" {
+		die(t, "flags[0].Value", "x", flags[0].Value)
+	}
+}
+
+func TestDashOption(t *testing.T) {
+	p := NewLongParser(parserOpts)
+	_, rest, _ := p.Parse([]string{"foo", "-"})
+	if len(rest) != 1 {
+		die(t, "rest", []string{"-"}, rest)
+	}
+	p.DashOption = true
+	flags := assertParse(t, p, []string{"foo", "-", "-a"}, []rune{'-', 'a'})
+	if flags[0].Name != "-" {
+		die(t, "flags[0].Name", "-", flags[0].Name)
+	}
+}
+
+func TestLongOnly(t *testing.T) {
+	p := NewLongParser(parserOpts)
+	p.LongOnly = true
+	flags := assertParse(t, p, []string{"foo", "-add", "-ħaġ", "x", "-ad"},
+		[]rune{'a', 'Ħ', 'a'})
+	if flags[1].Name != "-ħaġ" || !flags[1].IsLong || flags[1].Value != "x" {
+		die(t, "flags[1]", Flag{Key: 'Ħ', Name: "-ħaġ", Value: "x"}, flags[1])
+	}
+}
+
+func TestLongOnlyRules(t *testing.T) {
+	opts := []LongOpt{
+		{Short: 'a', Long: "all", Arg: None},
+		{Short: -1, Long: "add", Arg: None},
+		{Short: -2, Long: "verbose", Arg: None},
+		{Short: -3, Long: "version", Arg: None},
+		{Short: 'o', Long: "output", Arg: Required},
+		{Short: 'x', Arg: None},
+	}
+	p := NewLongParser(opts)
+	p.LongOnly = true
+
+	// A lone short-option is never a long-option
+	flags := assertParse(t, p, []string{"foo", "-a", "-ad", "-xa"}, []rune{'a', -1, 'x', 'a'})
+	if flags[1].Name != "-ad" || flags[2].Name != "-x" {
+		die(t, "flags", []string{"-a", "-ad", "-x", "-a"}, flags)
+	}
+	flags = assertParse(t, p, []string{"foo", "-output", "x", "-oy"}, []rune{'o', 'o'})
+	if flags[0].Value != "x" || flags[1].Value != "y" || flags[1].IsLong {
+		die(t, "flags", []string{"x", "y"}, flags)
+	}
+
+	tests := []struct {
+		arg  string
+		want error
+		msg  string
+	}{
+		{"-ver", AmbiguousOptionError{Long: "ver", Pos: 1,
+			Candidates: []string{"verbose", "version"}, OneDash: true},
+			"option ‘-ver’ is ambiguous; possibilities: ‘-verbose’ ‘-version’"},
+		{"-verbos=1", UnexpectedArgumentError{Long: "verbos", Value: "1", Pos: 1,
+			OneDash: true}, "option ‘-verbos’ doesn’t allow an argument"},
+		{"-zzz", BadOptionError{Long: "zzz", Pos: 1, OneDash: true},
+			"unknown option ‘-zzz’"},
+		{"-xz", BadOptionError{Short: 'z', Pos: 1}, "unknown option ‘-z’"},
+	}
+	for _, tt := range tests {
+		_, _, err := p.Parse([]string{"foo", tt.arg})
+		if !reflect.DeepEqual(err, tt.want) {
+			die(t, "err", tt.want, err)
+		}
+		if err.Error() != tt.msg {
+			die(t, "err.Error()", tt.msg, err.Error())
+		}
+	}
+
+	flags, _, err := GetLongOnly([]string{"foo", "-vers", "-all"}, opts)
+	if err != nil || len(flags) != 2 || flags[0].Index != 3 || flags[1].Index != 0 {
+		die(t, "GetLongOnly()", []int{3, 0}, flags)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	p := NewLongParser(parserOpts)
+	p.CollectErrors = true
+	args := []string{"foo", "-aXd", "--bad", "--add=x", "--ħaġrat"}
+	flags, _, err := p.Parse(args)
+	if len(flags) != 2 {
+		die(t, "flags", []rune{'a', 'd'}, flags)
+	}
+	var (
+		e1 BadOptionError
+		e2 UnexpectedArgumentError
+		e3 NoArgumentError
+	)
+	if !errors.As(err, &e1) || !errors.As(err, &e2) || !errors.As(err, &e3) {
+		die(t, "err", "joined errors", err)
+	}
+	if n := len(Errors(err)); n != 4 {
+		die(t, "len(Errors(err))", 4, n)
+	}
+}
+
+func TestCollectErrorsGet(t *testing.T) {
+	p := NewParser("ab:")
+	p.CollectErrors = true
+	args := []string{"foo", "-xay", "-z", "-b", "bar", "baz", "-a"}
+	flags, rest, err := p.Parse(args)
+	if len(flags) != 2 || flags[0].Key != 'a' || flags[1].Value != "bar" {
+		die(t, "flags", []rune{'a', 'b'}, flags)
+	}
+	if want := []string{"baz", "-a"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+	want := []error{
+		BadOptionError{Short: 'x', Pos: 1},
+		BadOptionError{Short: 'y', Pos: 1},
+		BadOptionError{Short: 'z', Pos: 2},
+	}
+	if errs := Errors(err); !reflect.DeepEqual(errs, want) {
+		die(t, "Errors(err)", want, errs)
+	}
+	if s := err.Error(); s != "unknown option ‘-x’\nunknown option ‘-y’\nunknown option ‘-z’" {
+		die(t, "err.Error()", "unknown option ‘-x’…", s)
+	}
+}
+
+func TestNumeric(t *testing.T) {
+	flags, rest, err := Get([]string{"foo", "-15", "-v3x", "-42", "-0", "bar"}, "#vx0")
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []Flag{
+		{Key: '#', Value: "15", Name: "-15"},
+		{Key: 'v', Name: "-v"},
+		{Key: '#', Value: "3", Name: "-3"},
+		{Key: 'x', Name: "-x"},
+		{Key: '#', Value: "42", Name: "-42"},
+		{Key: '0', Name: "-0"},
+	}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, w := range want {
+		if f := flags[i]; f.Key != w.Key || f.Value != w.Value || f.Name != w.Name {
+			die(t, fmt.Sprintf("flags[%d]", i), w, f)
+		}
+	}
+	if len(rest) != 1 {
+		die(t, "rest", []string{"bar"}, rest)
+	}
+
+	_, _, err = Get([]string{"foo", "-15"}, "v")
+	if want := (BadOptionError{Short: '1', Pos: 1}); !reflect.DeepEqual(err, want) {
+		die(t, "err", want, err)
+	}
+}
+
+func TestNumericLong(t *testing.T) {
+	opts := []LongOpt{
+		{Short: 'n', Long: "lines", Arg: Required, Numeric: true},
+		{Short: 'o', Long: "offset", Arg: Required},
+	}
+	args := []string{"foo", "-5", "-n", "-6", "--lines=7", "-o", "-8", "-n9"}
+	flags, _, err := GetLongOnly(args, opts)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []Flag{
+		{Key: 'n', Value: "5"},
+		{Key: 'n', Value: "-6"},
+		{Key: 'n', Value: "7"},
+		{Key: 'o', Value: "-8"},
+		{Key: 'n', Value: "9"},
+	}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, w := range want {
+		if f := flags[i]; f.Key != w.Key || f.Value != w.Value {
+			die(t, fmt.Sprintf("flags[%d]", i), w, f)
+		}
+	}
+	if s := Synopsis(opts); s != "[-NUM | -n arg] [-o arg]" {
+		die(t, "Synopsis()", "[-NUM | -n arg] [-o arg]", s)
+	}
+	want2 := "(-NUM | -n arg) [-o arg]"
+	if s := Synopsis(opts, RequireAll("lines")); s != want2 {
+		die(t, "Synopsis()", want2, s)
+	}
+	opts[0].Short = -1
+	if s := Synopsis(opts); s != "[-NUM] [-o arg]" {
+		die(t, "Synopsis()", "[-NUM] [-o arg]", s)
+	}
+}
+
+func TestPlusOptions(t *testing.T) {
+	p := NewLongParser(parserOpts)
+	p.PlusOptions = true
+	args := []string{"foo", "-a", "+ad", "++add", "+Ħx", "--delete", "+", "-d"}
+	flags, rest, err := p.Parse(args)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []Flag{
+		{Key: 'a', Name: "-a"},
+		{Key: 'a', Name: "+a", Plus: true},
+		{Key: 'd', Name: "+d", Plus: true},
+		{Key: 'a', Name: "++add", Plus: true},
+		{Key: 'Ħ', Name: "+Ħ", Value: "x", Plus: true},
+		{Key: 'd', Name: "--delete"},
+	}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, w := range want {
+		f := flags[i]
+		if f.Key != w.Key || f.Name != w.Name || f.Value != w.Value || f.Plus != w.Plus {
+			die(t, fmt.Sprintf("flags[%d]", i), w, f)
+		}
+	}
+	if want := []string{"+", "-d"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"+x", "unknown option ‘+x’"},
+		{"++ad=x", "option ‘++ad’ doesn’t allow an argument"},
+		{"+Ħ", "expected argument for option ‘+Ħ’"},
+	}
+	for _, tt := range tests {
+		_, _, err := p.Parse([]string{"foo", tt.arg})
+		if err == nil || err.Error() != tt.want {
+			die(t, "err", tt.want, err)
+		}
+	}
+
+	flags, rest, _ = NewLongParser(parserOpts).Parse([]string{"foo", "+a", "-a"})
+	if len(flags) != 0 || len(rest) != 2 {
+		die(t, "rest", []string{"+a", "-a"}, rest)
+	}
+}
@@ -0,0 +1,220 @@
+package opts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// origin describes where an argument came from.  The file is empty for
+// arguments given directly on the command-line.
+type origin struct {
+	file string
+	line int
+}
+
+// ExpandResponseFiles returns args with every response file expanded, in
+// the same manner as a [Parser] with ResponseFiles set.  The first
+// element of args is assumed to be the program name and is never
+// expanded.
+func ExpandResponseFiles(args []string) ([]string, error) {
+	args, _, err := expandResponseFiles(args)
+	return args, err
+}
+
+// expandResponseFiles expands the response files in args, returning the
+// expanded arguments along with the origin of each one.
+func expandResponseFiles(args []string) ([]string, []origin, error) {
+	if len(args) == 0 {
+		return args, nil, nil
+	}
+	x := expander{
+		args:    []string{args[0]},
+		origins: []origin{{}},
+	}
+	for _, arg := range args[1:] {
+		if !x.stop && len(arg) > 1 && arg[0] == '@' {
+			if err := x.expand(arg[1:], origin{}); err != nil {
+				x.errs = append(x.errs, err)
+			}
+			continue
+		}
+		x.add(arg, origin{})
+	}
+	return x.args, x.origins, errors.Join(x.errs...)
+}
+
+type expander struct {
+	args    []string
+	origins []origin
+	stack   []string // the response files being expanded
+	errs    []error
+	stop    bool // ‘--’ has been reached
+}
+
+func (x *expander) add(arg string, o origin) {
+	x.args = append(x.args, arg)
+	x.origins = append(x.origins, o)
+	if arg == "--" {
+		x.stop = true
+	}
+}
+
+// expand expands the response file name, which was given at from.
+func (x *expander) expand(name string, from origin) error {
+	wrap := func(err error) error {
+		if from.file == "" {
+			return err
+		}
+		return ResponseFileError{File: from.file, Line: from.line, Err: err}
+	}
+
+	key, err := filepath.Abs(name)
+	if err != nil {
+		key = name
+	}
+	for _, s := range x.stack {
+		if s == key {
+			return wrap(fmt.Errorf("response file ‘%s’ includes itself", name))
+		}
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return wrap(err)
+	}
+	words, err := splitResponseFile(string(data), name)
+	if err != nil {
+		return err
+	}
+
+	x.stack = append(x.stack, key)
+	defer func() { x.stack = x.stack[:len(x.stack)-1] }()
+	for _, w := range words {
+		o := origin{name, w.line}
+		if !x.stop && len(w.text) > 1 && w.text[0] == '@' {
+			if err := x.expand(w.text[1:], o); err != nil {
+				x.errs = append(x.errs, err)
+			}
+			continue
+		}
+		x.add(w.text, o)
+	}
+	return nil
+}
+
+type word struct {
+	text string
+	line int
+}
+
+// splitResponseFile splits the contents of the response file name into
+// words.  Words are separated by whitespace, and may be quoted with
+// single- or double-quotes as in a POSIX shell.  Outside of single-quotes
+// a backslash escapes the following character, while a backslash followed
+// by a newline is removed entirely.  A ‘#’ at the start of a word begins
+// a comment which extends to the end of the line.
+func splitResponseFile(data, name string) ([]word, error) {
+	var ws []word
+	var sb strings.Builder
+	var quote rune
+	rs := []rune(data)
+	line, start, inWord := 1, 0, false
+
+	begin := func() {
+		if !inWord {
+			inWord, start = true, line
+		}
+	}
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case quote == '\'' && r != '\'', quote == '"' && r != '"' && r != '\\':
+			sb.WriteRune(r)
+		case quote != 0 && r == quote:
+			quote = 0
+		case r == '\\':
+			if i+1 == len(rs) {
+				begin()
+				sb.WriteRune(r)
+				break
+			}
+			i++
+			switch {
+			case rs[i] == '\n':
+				line++
+				continue
+			case quote == '"' && rs[i] != '"' && rs[i] != '\\':
+				sb.WriteRune(r)
+			}
+			begin()
+			sb.WriteRune(rs[i])
+			continue
+		case r == '\'' || r == '"':
+			begin()
+			quote = r
+		case unicode.IsSpace(r):
+			if inWord {
+				ws = append(ws, word{sb.String(), start})
+				sb.Reset()
+				inWord = false
+			}
+		case r == '#' && !inWord:
+			for i+1 < len(rs) && rs[i+1] != '\n' {
+				i++
+			}
+		default:
+			begin()
+			sb.WriteRune(r)
+		}
+		if r == '\n' {
+			line++
+		}
+	}
+
+	if quote != 0 {
+		return nil, ResponseFileError{File: name, Line: start,
+			Err: fmt.Errorf("unterminated quote %c", quote)}
+	}
+	if inWord {
+		ws = append(ws, word{sb.String(), start})
+	}
+	return ws, nil
+}
+
+// locate wraps each of the errors in err that concern an argument read
+// from a response file in a [ResponseFileError].
+func locate(err error, origins []origin) error {
+	if err == nil || origins == nil {
+		return err
+	}
+	errs := Errors(err)
+	for i, e := range errs {
+		pos := -1
+		switch e := e.(type) {
+		case BadOptionError:
+			pos = e.Pos
+		case AmbiguousOptionError:
+			pos = e.Pos
+		case NoArgumentError:
+			pos = e.Pos
+		case UnexpectedArgumentError:
+			pos = e.Pos
+		case DuplicateOptionError:
+			pos = e.Pos
+		case ValueError:
+			pos = e.Flag.Pos
+		}
+		if pos >= 0 && pos < len(origins) && origins[pos].file != "" {
+			o := origins[pos]
+			errs[i] = ResponseFileError{File: o.file, Line: o.line, Err: e}
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,130 @@
+package opts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files to a temporary directory, replacing ‘$D’ in
+// their contents with the directory, and returns the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		data = strings.ReplaceAll(data, "$D", dir)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSplitResponseFile(t *testing.T) {
+	data := `-a 'single quoted' "double \"quoted\" \n"
+# a comment
+esc\ aped\
+continued ''"" not#comment
+"multi
+line" @x.rsp`
+	ws, err := splitResponseFile(data, "args.rsp")
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []word{
+		{"-a", 1}, {"single quoted", 1}, {`double "quoted" \n`, 1},
+		{"esc aped" + "continued", 3}, {"", 4}, {"not#comment", 4},
+		{"multi\nline", 5}, {"@x.rsp", 6},
+	}
+	if !reflect.DeepEqual(ws, want) {
+		die(t, "words", want, ws)
+	}
+
+	_, err = splitResponseFile("-a\n'oops", "args.rsp")
+	if s := err.Error(); s != "args.rsp:2: unterminated quote '" {
+		die(t, "err.Error()", "args.rsp:2: unterminated quote '", s)
+	}
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.rsp": "-a\n@$D/b.rsp --add\n-- @c.rsp",
+		"b.rsp": "-c 'x y'\n",
+	})
+	a, b := filepath.Join(dir, "a.rsp"), filepath.Join(dir, "b.rsp")
+
+	p := NewLongParser([]LongOpt{
+		{Short: 'a', Long: "add", Arg: None},
+		{Short: 'c', Long: "change", Arg: Required},
+	})
+	p.ResponseFiles = true
+	flags, rest, err := p.Parse([]string{"foo", "@" + a, "@"})
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	if want := []string{"@c.rsp", "@"}; !slices.Equal(rest, want) {
+		die(t, "rest", want, rest)
+	}
+	want := []struct {
+		key   rune
+		value string
+		file  string
+		line  int
+	}{
+		{'a', "", a, 1},
+		{'c', "x y", b, 1},
+		{'a', "", a, 2},
+	}
+	if len(flags) != len(want) {
+		die(t, "flags", want, flags)
+	}
+	for i, w := range want {
+		f := flags[i]
+		if f.Key != w.key || f.Value != w.value || f.File != w.file || f.Line != w.line {
+			die(t, "flags", w, f)
+		}
+	}
+	if s := flags[1].Origin(); s != b+":1 (‘-c’)" {
+		die(t, "flags[1].Origin()", b+":1 (‘-c’)", s)
+	}
+}
+
+func TestResponseFileErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.rsp": "-a\n\n--bad @$D/b.rsp",
+		"b.rsp": "\n@$D/a.rsp",
+		"c.rsp": "@$D/missing.rsp",
+	})
+	a, b, c := filepath.Join(dir, "a.rsp"), filepath.Join(dir, "b.rsp"),
+		filepath.Join(dir, "c.rsp")
+
+	p := NewLongParser([]LongOpt{{Short: 'a', Long: "add", Arg: None}})
+	p.ResponseFiles = true
+	p.CollectErrors = true
+	_, _, err := p.Parse([]string{"foo", "@" + a, "@" + c})
+	errs := Errors(err)
+	if len(errs) != 3 {
+		die(t, "Errors(err)", "3 errors", errs)
+	}
+	want := b + ":2: response file ‘" + a + "’ includes itself"
+	if s := errs[0].Error(); s != want {
+		die(t, "errs[0]", want, s)
+	}
+	var re ResponseFileError
+	if !errors.As(errs[1], &re) || re.File != c || !errors.Is(re, os.ErrNotExist) {
+		die(t, "errs[1]", "c.rsp:1: open missing.rsp: no such file or directory", errs[1])
+	}
+	var be BadOptionError
+	want = a + ":3: unknown option ‘--bad’"
+	if !errors.As(errs[2], &be) || errs[2].Error() != want {
+		die(t, "errs[2]", want, errs[2])
+	}
+
+	_, err = ExpandResponseFiles([]string{"foo", "@missing.rsp"})
+	if !errors.Is(err, os.ErrNotExist) {
+		die(t, "err", os.ErrNotExist, err)
+	}
+}
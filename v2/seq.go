@@ -0,0 +1,22 @@
+//go:build go1.23
+
+package opts
+
+import "iter"
+
+// All returns an iterator over the remaining flags of it, yielding each
+// flag or error in turn as returned by [Iter.Next].  Iteration may be
+// stopped and later resumed by ranging over All again.
+func (it *Iter) All() iter.Seq2[Flag, error] {
+	return func(yield func(Flag, error) bool) {
+		for {
+			f, ok, err := it.Next()
+			if !ok && err == nil {
+				return
+			}
+			if !yield(f, err) {
+				return
+			}
+		}
+	}
+}
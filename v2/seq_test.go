@@ -0,0 +1,25 @@
+//go:build go1.23
+
+package opts
+
+import "testing"
+
+func TestIterAll(t *testing.T) {
+	it := NewParser("ab").Iter([]string{"foo", "-ab", "-b", "bar"})
+	n := 0
+	for f, err := range it.All() {
+		if err != nil {
+			die(t, "err", nil, err)
+		}
+		if f.Key == 'b' {
+			break
+		}
+		n++
+	}
+	for range it.All() {
+		n++
+	}
+	if n != 2 {
+		die(t, "n", 2, n)
+	}
+}
@@ -0,0 +1,114 @@
+package opts
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CompileOptstr returns the options described by optstr, which is
+// interpreted as it would be by [Get].  The returned options may be used
+// with the functions of this package which take a [LongOpt] slice, such
+// as [Synopsis] and [Help].
+//
+// If optstr is invalid, the options are returned along with a [SpecError]
+// listing every problem with optstr.  An optstr is invalid if it is not
+// valid UTF-8, if an option is followed by more than two colons, or if an
+// option is listed more than once.
+func CompileOptstr(optstr string) ([]LongOpt, error) {
+	var os []LongOpt
+	var problems []string
+	if !utf8.ValidString(optstr) {
+		problems = append(problems, "option string is not valid UTF-8")
+	}
+
+	rs := []rune(optstr)
+	i := 0
+	if len(rs) > 0 && rs[0] == ':' {
+		i++
+	}
+	for i < len(rs) {
+		r := rs[i]
+		n := 0
+		for i++; i < len(rs) && rs[i] == ':'; i++ {
+			n++
+		}
+		if r == ':' {
+			problems = append(problems, "‘:’ does not follow an option")
+			continue
+		}
+		if n > 2 {
+			problems = append(problems,
+				fmt.Sprintf("option ‘-%c’ is followed by %d colons", r, n))
+		}
+		os = append(os, LongOpt{
+			Short:   r,
+			Arg:     colonsToArgMode(rs[i-n:]),
+			Numeric: r == '#',
+		})
+	}
+
+	problems = append(problems, specProblems(os)...)
+	if len(problems) > 0 {
+		return os, SpecError{Problems: problems}
+	}
+	return os, nil
+}
+
+// ValidateOpts returns a [SpecError] listing every problem with opts, or
+// nil if there are none.  The options in opts are invalid if an option
+// has neither a short- nor a long-form, if a short-form other than a
+// negative one is shared by multiple options, if a long-form is shared by
+// multiple options, including the negated forms of Negatable options, if
+// a long-form contains ‘=’, if an option has an invalid Arg, if a
+// Negatable option has no long-form, or if multiple options are Numeric.
+func ValidateOpts(opts []LongOpt) error {
+	if problems := specProblems(opts); len(problems) > 0 {
+		return SpecError{Problems: problems}
+	}
+	return nil
+}
+
+func specProblems(opts []LongOpt) []string {
+	var problems []string
+	shorts := make(map[rune]int)
+	longs := make(map[string]int)
+	numeric := 0
+	for i, o := range opts {
+		if o.Short <= 0 && o.Long == "" {
+			problems = append(problems,
+				fmt.Sprintf("option %d has neither a short- nor a long-form", i))
+			continue
+		}
+		if o.Short > 0 {
+			if shorts[o.Short]++; shorts[o.Short] == 2 {
+				problems = append(problems,
+					fmt.Sprintf("short-option ‘-%c’ is given more than once", o.Short))
+			}
+		}
+		for _, l := range longForms(o) {
+			if longs[l]++; longs[l] == 2 {
+				problems = append(problems,
+					fmt.Sprintf("long-option ‘--%s’ is given more than once", l))
+			}
+		}
+		if strings.ContainsRune(o.Long, '=') {
+			problems = append(problems,
+				fmt.Sprintf("long-option ‘--%s’ contains ‘=’", o.Long))
+		}
+		if o.Arg < None || o.Arg > Optional {
+			problems = append(problems,
+				fmt.Sprintf("option ‘%s’ has invalid argument mode %d", optName(o), o.Arg))
+		}
+		if o.Negatable && o.Long == "" {
+			problems = append(problems,
+				fmt.Sprintf("option ‘%s’ is negatable but has no long-form", optName(o)))
+		}
+		if o.Numeric {
+			if numeric++; numeric == 2 {
+				problems = append(problems, "more than one option is numeric")
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,89 @@
+package opts
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompileOptstr(t *testing.T) {
+	opts, err := CompileOptstr(":a:b::c#")
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := []LongOpt{
+		{Short: 'a', Arg: Required},
+		{Short: 'b', Arg: Optional},
+		{Short: 'c', Arg: None},
+		{Short: '#', Arg: None, Numeric: true},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		die(t, "opts", want, opts)
+	}
+
+	_, err = CompileOptstr("a:::ba:\xff")
+	want2 := SpecError{Problems: []string{
+		"option string is not valid UTF-8",
+		"option ‘-a’ is followed by 3 colons",
+		"short-option ‘-a’ is given more than once",
+	}}
+	if !reflect.DeepEqual(err, want2) {
+		die(t, "err", want2, err)
+	}
+}
+
+func TestValidateOpts(t *testing.T) {
+	err := ValidateOpts([]LongOpt{
+		{Short: 'a', Long: "add", Arg: None},
+		{Short: 'a', Long: "all", Arg: None},
+		{Short: -1, Long: "add", Arg: None},
+		{Short: -1, Long: "color", Arg: None, Negatable: true},
+		{Short: -2, Long: "no-color", Arg: None},
+		{Short: -3, Long: "key=value", Arg: Required},
+		{Short: 'x', Arg: 3},
+		{Short: 'y', Arg: None, Negatable: true},
+		{Short: -4},
+	})
+	want := SpecError{Problems: []string{
+		"short-option ‘-a’ is given more than once",
+		"long-option ‘--add’ is given more than once",
+		"long-option ‘--no-color’ is given more than once",
+		"long-option ‘--key=value’ contains ‘=’",
+		"option ‘-x’ has invalid argument mode 3",
+		"option ‘-y’ is negatable but has no long-form",
+		"option 8 has neither a short- nor a long-form",
+	}}
+	if !reflect.DeepEqual(err, want) {
+		die(t, "err", want, err)
+	}
+
+	if err := ValidateOpts(parserOpts); err != nil {
+		die(t, "err", nil, err)
+	}
+}
+
+func TestParserSpecError(t *testing.T) {
+	args := []string{"foo", "-a", "-b"}
+	if _, _, err := NewParser("aa").Parse(args); !errors.As(err, new(SpecError)) {
+		die(t, "err", SpecError{}, err)
+	}
+	it := NewParser("aa").Iter(args)
+	if _, ok, err := it.Next(); ok || !errors.As(err, new(SpecError)) {
+		die(t, "err", SpecError{}, err)
+	}
+	if _, ok, err := it.Next(); ok || err != nil {
+		die(t, "err", nil, err)
+	}
+
+	// Get, GetLong, and GetLongOnly remain lenient
+	if _, _, err := Get(args, "aab"); err != nil {
+		die(t, "err", nil, err)
+	}
+	opts := []LongOpt{{Short: 'a', Arg: None}, {Short: 'a', Arg: None}}
+	if _, _, err := GetLong(args[:2], opts); err != nil {
+		die(t, "err", nil, err)
+	}
+	if _, _, err := GetLongOnly(args[:2], opts); err != nil {
+		die(t, "err", nil, err)
+	}
+}
@@ -0,0 +1,256 @@
+package opts
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// GetStruct parses the command-line arguments in args according to the
+// struct pointed to by v, storing the arguments of the parsed flags in
+// the structs fields.  The non-option arguments are returned in rest.
+//
+// Each field to be parsed must be tagged with an ‘opts’ key holding
+// the short-form, long-form, and argument mode of the option separated by
+// commas.  The short- or long-form may be omitted, as may the argument
+// mode.  Options without a short-form are still matched as unicode long
+// options, prefix-matching and all:
+//
+//	var cfg struct {
+//		Add     string        `opts:"a,add,required"`
+//		Verbose bool          `opts:"v,verbose"`
+//		Timeout time.Duration `opts:",timeout"`
+//		Include []string      `opts:"I"`
+//		Colour  string        `opts:",colour,optional"`
+//	}
+//	rest, err := opts.GetStruct(os.Args, &cfg)
+//
+// The argument mode is one of ‘none’, ‘required’, or ‘optional’ and
+// defaults to ‘none’ for boolean fields and ‘required’ otherwise.  The
+// mode may also be ‘negatable’, which is as ‘none’ but additionally
+// accepts the negated form of the option (see [LongOpt]), which sets
+// boolean fields to false.  Note that the ‘required’ mode only means that
+// the option requires an argument; it does not make the option itself
+// mandatory.  To require that an option be given, use a [Parser] with
+// [RequireAll] in its Constraints.
+//
+// Fields may be strings, booleans, integers, floats, [time.Duration]s,
+// or any type implementing [encoding.TextUnmarshaler].  Slices of these
+// types are appended to each time their option is given, while all other
+// fields are overwritten.  Boolean fields are set to true when their
+// option is given without an argument.  Non-boolean fields whose option
+// takes an optional argument are left unchanged if their option is given
+// without one.
+//
+// In the case of failure err will be one of the errors returned by
+// [GetLong], a [ValueError] if an argument could not be converted to the
+// type of its field, or a [SpecError] if multiple fields share an option.
+// The struct pointed to by v is only modified if parsing succeeds.
+func GetStruct(args []string, v any) (rest []string, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("opts: GetStruct requires a pointer to a struct")
+	}
+	rv = rv.Elem()
+
+	opts, fields, err := structOpts(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateOpts(opts); err != nil {
+		return nil, err
+	}
+
+	flags, rest, err := GetLong(args, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill a copy so that v is left untouched if any argument is invalid
+	cp := reflect.New(rv.Type()).Elem()
+	cp.Set(rv)
+	for _, f := range flags {
+		if err := setField(cp.Field(fields[f.Index]), f, opts[f.Index].Arg); err != nil {
+			return nil, ValueError{Flag: f, Err: err}
+		}
+	}
+	rv.Set(cp)
+	return rest, nil
+}
+
+// structOpts returns the options described by the tags of the struct
+// type t, along with the index of the field that each option belongs to.
+func structOpts(t reflect.Type) (opts []LongOpt, fields []int, err error) {
+	short := rune(-1)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("opts")
+		if !ok || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, nil, fmt.Errorf("opts: field %s is not exported", sf.Name)
+		}
+		if !supportedType(sf.Type) {
+			return nil, nil, fmt.Errorf("opts: field %s has unsupported type %s",
+				sf.Name, sf.Type)
+		}
+
+		var o LongOpt
+		parts := strings.Split(tag, ",")
+		if len(parts) > 3 {
+			return nil, nil, fmt.Errorf("opts: field %s has malformed tag %q",
+				sf.Name, tag)
+		}
+
+		switch s := parts[0]; utf8.RuneCountInString(s) {
+		case 0:
+			o.Short = short
+			short--
+		case 1:
+			o.Short, _ = utf8.DecodeRuneInString(s)
+		default:
+			return nil, nil, fmt.Errorf("opts: field %s has multi-rune short option %q",
+				sf.Name, s)
+		}
+
+		if len(parts) > 1 {
+			o.Long = parts[1]
+		}
+		if o.Long == "" && o.Short < 0 {
+			return nil, nil, fmt.Errorf("opts: field %s has no option name", sf.Name)
+		}
+
+		o.Arg = Required
+		if elemType(sf.Type).Kind() == reflect.Bool {
+			o.Arg = None
+		}
+		if len(parts) > 2 {
+			switch parts[2] {
+			case "none":
+				o.Arg = None
+			case "required":
+				o.Arg = Required
+			case "optional":
+				o.Arg = Optional
+			case "negatable":
+				o.Arg, o.Negatable = None, true
+			default:
+				return nil, nil, fmt.Errorf("opts: field %s has unknown argument mode %q",
+					sf.Name, parts[2])
+			}
+		}
+
+		opts = append(opts, o)
+		fields = append(fields, i)
+	}
+	return opts, fields, nil
+}
+
+// elemType returns the element type of t if t is a slice, and t
+// otherwise.
+func elemType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Slice && !reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return t.Elem()
+	}
+	return t
+}
+
+func supportedType(t reflect.Type) bool {
+	t = elemType(t)
+	if t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func setField(v reflect.Value, f Flag, am ArgMode) error {
+	if f.Negated {
+		if v.Kind() == reflect.Bool {
+			v.SetBool(false)
+		}
+		return nil
+	}
+	hasArg := am == Required || am == Optional && f.Inline
+	if t := elemType(v.Type()); t != v.Type() {
+		if !hasArg && t.Kind() != reflect.Bool {
+			return nil
+		}
+		e := reflect.New(t).Elem()
+		if err := setValue(e, f.Value, hasArg); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, e))
+		return nil
+	}
+	if !hasArg && v.Kind() != reflect.Bool {
+		return nil
+	}
+	return setValue(v, f.Value, hasArg)
+}
+
+func setValue(v reflect.Value, s string, hasArg bool) error {
+	if !hasArg {
+		v.SetBool(true)
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package opts
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type structOptsTest struct {
+	Add     string        `opts:"a,add,required"`
+	Verbose bool          `opts:"v,verbose"`
+	Level   int8          `opts:"l,level"`
+	Size    uint          `opts:",size"`
+	Ratio   float64       `opts:"r"`
+	Timeout time.Duration `opts:",timeout"`
+	Include []string      `opts:"I,include"`
+	Colour  string        `opts:"ç,colour,optional"`
+	Addr    netip.Addr    `opts:",addr"`
+	Ignored string
+}
+
+func TestGetStruct(t *testing.T) {
+	var cfg structOptsTest
+	args := []string{"foo", "-vaxyz", "--lev=-3", "--si", "0x10", "-r1.5",
+		"--time=1m30s", "-Ifoo", "--inc", "bar", "-ç", "--addr", "::1", "baz"}
+	rest, err := GetStruct(args, &cfg)
+	if err != nil {
+		die(t, "err", nil, err)
+	}
+	want := structOptsTest{
+		Add:     "xyz",
+		Verbose: true,
+		Level:   -3,
+		Size:    16,
+		Ratio:   1.5,
+		Timeout: 90 * time.Second,
+		Include: []string{"foo", "bar"},
+		Addr:    netip.MustParseAddr("::1"),
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		die(t, "cfg", want, cfg)
+	}
+	if len(rest) != 1 || rest[0] != "baz" {
+		die(t, "rest", []string{"baz"}, rest)
+	}
+}
+
+func TestGetStructOptional(t *testing.T) {
+	cfg := structOptsTest{Colour: "auto"}
+	if _, err := GetStruct([]string{"foo", "--colour"}, &cfg); err != nil {
+		die(t, "err", nil, err)
+	}
+	if cfg.Colour != "auto" {
+		die(t, "cfg.Colour", "auto", cfg.Colour)
+	}
+	if _, err := GetStruct([]string{"foo", "-çnever"}, &cfg); err != nil {
+		die(t, "err", nil, err)
+	}
+	if cfg.Colour != "never" {
+		die(t, "cfg.Colour", "never", cfg.Colour)
+	}
+}
+
+func TestGetStructBadValue(t *testing.T) {
+	cfg := structOptsTest{Include: []string{"x"}}
+	_, err := GetStruct([]string{"foo", "-va", "y", "-Iz", "-l", "300"}, &cfg)
+	var e ValueError
+	if !errors.As(err, &e) {
+		die(t, "err", ValueError{}, err)
+	}
+	if e.Flag.Name != "-l" || !errors.Is(err, strconv.ErrRange) {
+		die(t, "err", "invalid argument ‘300’ for option ‘-l’: value out of range", err)
+	}
+	if want := (structOptsTest{Include: []string{"x"}}); !reflect.DeepEqual(cfg, want) {
+		die(t, "cfg", want, cfg)
+	}
+}
+
+func TestGetStructBadTag(t *testing.T) {
+	var cfg struct {
+		Foo chan int `opts:"f"`
+	}
+	if _, err := GetStruct([]string{"foo"}, &cfg); err == nil {
+		die(t, "err", "opts: field Foo has unsupported type chan int", err)
+	}
+	if _, err := GetStruct([]string{"foo"}, cfg); err == nil {
+		die(t, "err", "opts: GetStruct requires a pointer to a struct", err)
+	}
+}
+
+func TestGetStructNegatable(t *testing.T) {
+	cfg := struct {
+		Color bool `opts:"c,color,negatable"`
+	}{Color: true}
+	if _, err := GetStruct([]string{"foo", "--no-col"}, &cfg); err != nil {
+		die(t, "err", nil, err)
+	}
+	if cfg.Color {
+		die(t, "cfg.Color", false, cfg.Color)
+	}
+}
+
+func TestGetStructDuplicate(t *testing.T) {
+	var cfg struct {
+		Add bool `opts:"a,add"`
+		All bool `opts:"a,all"`
+	}
+	_, err := GetStruct([]string{"foo", "-a"}, &cfg)
+	want := SpecError{Problems: []string{"short-option ‘-a’ is given more than once"}}
+	if !reflect.DeepEqual(err, want) {
+		die(t, "err", want, err)
+	}
+}
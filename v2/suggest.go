@@ -0,0 +1,90 @@
+package opts
+
+import (
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSuggestions is the most suggestions that are given for an unknown
+// long-option.
+const maxSuggestions = 3
+
+// suggest returns the long-forms of the options in os that are most
+// similar to s, most similar first.
+func suggest(os []LongOpt, s string) []string {
+	type cand struct {
+		long string
+		dist int
+	}
+
+	limit := 2 * max(1, utf8.RuneCountInString(s)/3)
+	var cs []cand
+	for _, o := range os {
+		if o.Long == "" || slices.ContainsFunc(cs, func(c cand) bool {
+			return c.long == o.Long
+		}) {
+			continue
+		}
+		if d := editDistance(s, o.Long); d <= limit {
+			cs = append(cs, cand{o.Long, d})
+		}
+	}
+
+	slices.SortStableFunc(cs, func(a, b cand) int {
+		return a.dist - b.dist
+	})
+	var ss []string
+	for i := 0; i < len(cs) && i < maxSuggestions; i++ {
+		ss = append(ss, cs[i].long)
+	}
+	return ss
+}
+
+// editDistance returns the optimal string alignment distance between the
+// runes of a and b.  Insertions, deletions, and substitutions cost 2,
+// while the more likely typo of transposing two runes costs 1.  Changing
+// the case of a rune or confusing ‘-’ with ‘_’ costs nothing.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = 2 * i
+	}
+	for j := range d[0] {
+		d[0][j] = 2 * j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			d[i][j] = min(
+				d[i-1][j]+2,
+				d[i][j-1]+2,
+				d[i-1][j-1]+substCost(ra[i-1], rb[j-1]),
+			)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func substCost(a, b rune) int {
+	switch {
+	case a == b, equalFold(a, b), a == '-' && b == '_', a == '_' && b == '-':
+		return 0
+	}
+	return 2
+}
+
+// equalFold reports whether a and b are equal under unicode case-folding.
+func equalFold(a, b rune) bool {
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
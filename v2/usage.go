@@ -0,0 +1,286 @@
+package opts
+
+import (
+	"strings"
+	"unicode"
+)
+
+// helpColumn is the widest that the option column of [Help] may grow
+// before descriptions are moved onto their own lines.
+const helpColumn = 30
+
+// Synopsis returns a synopsis of the options in opts, suitable for use
+// in a usage message.  Options without arguments are grouped together,
+// and options are given in their short-form when they have one.  For
+// example, given the following definition of opts:
+//
+//	opts := []LongOpt{
+//		{Short: 'a', Long: "add", Arg: Required},
+//		{Short: 'ß', Long: "sheiße", Arg: None},
+//		{Short: 'λ', Long: "λεωνίδας", Arg: None},
+//		{Short: -1, Long: "no-short", Arg: None},
+//	}
+//
+// Synopsis returns ‘[-ßλ] [-a arg] [--no-short]’.
+//
+// If any constraints are given in cs, they are reflected in the synopsis.
+// Options required by [RequireAll] are not surrounded in brackets, while
+// the options of an [Exclusive] group are listed together as
+// ‘[-a arg | -ß]’ and those of an [AtLeastOne] group as ‘(-a arg | -ß)’.
+// Constraints that refer to options not in opts are ignored.
+//
+// Numeric options are given in their numeric form, as ‘-’ followed by
+// their ArgName, or ‘NUM’ if they have none.  If a Numeric option also
+// has a short-form that takes an argument, both forms are given, as in
+// ‘[-NUM | -n arg]’.
+func Synopsis(opts []LongOpt, cs ...Constraint) string {
+	required := make([]bool, len(opts))
+	group := make([]int, len(opts))
+	for i := range group {
+		group[i] = -1
+	}
+	for j, c := range cs {
+		is, _ := c.indices(opts)
+		for _, i := range is {
+			switch c.kind {
+			case requireAll:
+				required[i] = true
+			case exclusive, atLeastOne:
+				if group[i] == -1 {
+					group[i] = j
+				}
+			}
+		}
+	}
+
+	var cluster []rune
+	var parts, longs []string
+	done := make([]bool, len(cs))
+	for i, o := range opts {
+		switch g := group[i]; {
+		case g != -1:
+			if done[g] {
+				continue
+			}
+			done[g] = true
+			var ms []string
+			for j, o := range opts {
+				if group[j] == g {
+					ms = append(ms, optUsage(o))
+				}
+			}
+			s := strings.Join(ms, " | ")
+			if cs[g].kind == exclusive {
+				parts = append(parts, "["+s+"]")
+			} else {
+				parts = append(parts, "("+s+")")
+			}
+		case required[i] && o.Short <= 0 && !o.Numeric:
+			longs = append(longs, longUsage(o))
+		case required[i] && o.Numeric && o.Short > 0 && o.Arg != None:
+			parts = append(parts, "("+shortUsage(o)+")")
+		case required[i]:
+			parts = append(parts, shortUsage(o))
+		case o.Short <= 0 && !o.Numeric:
+			longs = append(longs, "["+longUsage(o)+"]")
+		case o.Arg == None && !o.Numeric:
+			cluster = append(cluster, o.Short)
+		default:
+			parts = append(parts, "["+shortUsage(o)+"]")
+		}
+	}
+	if len(cluster) > 0 {
+		parts = append([]string{"[-" + string(cluster) + "]"}, parts...)
+	}
+	return strings.Join(append(parts, longs...), " ")
+}
+
+// Help returns a GNU-style table describing the options in opts.  Each
+// option is listed on its own line alongside its Help and Default, with
+// the descriptions of all the options aligned in a single column:
+//
+//	-a, --add=arg   add an item
+//	-ß, --sheiße    be rude
+//	    --no-short  an option without a short-form
+//
+// Column widths are measured in terms of the display width of the
+// options, so unicode options are aligned correctly.  If any constraints
+// are given in cs, the descriptions of the options they apply to are
+// annotated with notes such as ‘(required)’ or ‘(conflicts with --yaml)’.
+// As with [Synopsis], constraints that refer to options not in opts are
+// ignored.
+func Help(opts []LongOpt, cs ...Constraint) string {
+	notes := constraintNotes(opts, cs)
+	cols := make([]string, len(opts))
+	width := 0
+	for i, o := range opts {
+		switch {
+		case o.Long == "":
+			cols[i] = shortUsage(o)
+		case o.Short <= 0 && !o.Numeric:
+			cols[i] = "    " + longUsage(o)
+		case o.Short <= 0:
+			cols[i] = "-" + numName(o) + ", " + longUsage(o)
+		default:
+			cols[i] = "-" + string(o.Short) + ", " + longUsage(o)
+		}
+		if w := stringWidth(cols[i]); w <= helpColumn {
+			width = max(width, w)
+		}
+	}
+
+	var sb strings.Builder
+	for i, o := range opts {
+		desc := o.Help
+		if o.Default != "" {
+			if desc != "" {
+				desc += " "
+			}
+			desc += "(default: " + o.Default + ")"
+		}
+		if len(notes[i]) > 0 {
+			if desc != "" {
+				desc += " "
+			}
+			desc += "(" + strings.Join(notes[i], "; ") + ")"
+		}
+
+		sb.WriteString("  ")
+		sb.WriteString(cols[i])
+		if desc == "" {
+			sb.WriteByte('\n')
+			continue
+		}
+
+		pad := width - stringWidth(cols[i]) + 2
+		if pad < 2 {
+			sb.WriteByte('\n')
+			pad = width + 4
+		}
+		for j, line := range strings.Split(desc, "\n") {
+			if j > 0 {
+				pad = width + 4
+			}
+			sb.WriteString(strings.Repeat(" ", pad))
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func argName(o LongOpt) string {
+	if o.ArgName != "" {
+		return o.ArgName
+	}
+	return "arg"
+}
+
+// optUsage returns the usage of o in its short-form if it has one, and in
+// its long-form otherwise.
+func optUsage(o LongOpt) string {
+	if o.Short <= 0 && !o.Numeric {
+		return longUsage(o)
+	}
+	return shortUsage(o)
+}
+
+// numName returns the name of the number given to the Numeric option o.
+func numName(o LongOpt) string {
+	if o.ArgName != "" {
+		return o.ArgName
+	}
+	return "NUM"
+}
+
+// shortUsage returns the usage of o in its short-form.  Numeric options
+// that also have a short-form taking an argument are given in both
+// forms, as ‘-NUM | -n arg’.
+func shortUsage(o LongOpt) string {
+	if o.Numeric {
+		if o.Short <= 0 || o.Arg == None {
+			return "-" + numName(o)
+		}
+		o.Numeric = false
+		return "-" + numName(o) + " | " + shortUsage(o)
+	}
+	switch o.Arg {
+	case Required:
+		return "-" + string(o.Short) + " " + argName(o)
+	case Optional:
+		return "-" + string(o.Short) + "[" + argName(o) + "]"
+	}
+	return "-" + string(o.Short)
+}
+
+func longUsage(o LongOpt) string {
+	l := "--" + o.Long
+	if o.Negatable {
+		l = "--[no-]" + o.Long
+	}
+	switch o.Arg {
+	case Required:
+		return l + "=" + argName(o)
+	case Optional:
+		return l + "[=" + argName(o) + "]"
+	}
+	return l
+}
+
+// stringWidth returns the number of columns that s occupies when
+// displayed in a terminal.
+func stringWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of columns that r occupies when displayed
+// in a terminal.  Combining and formatting characters occupy no columns,
+// while east-asian wide and fullwidth characters occupy two.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0, unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf),
+		r >= 0x1160 && r <= 0x11FF:
+		return 0
+	case !unicode.IsPrint(r) && !unicode.IsSpace(r):
+		return 0
+	}
+	for _, rg := range wideRanges {
+		if r < rg[0] {
+			break
+		}
+		if r <= rg[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// wideRanges lists the ranges of east-asian wide and fullwidth
+// characters, sorted in ascending order.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A},
+	{0x23E9, 0x23EC}, {0x23F0, 0x23F0}, {0x23F3, 0x23F3},
+	{0x25FD, 0x25FE}, {0x2614, 0x2615}, {0x2648, 0x2653},
+	{0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5},
+	{0x26CE, 0x26CE}, {0x26D4, 0x26D4}, {0x26EA, 0x26EA},
+	{0x26F2, 0x26F3}, {0x26F5, 0x26F5}, {0x26FA, 0x26FA},
+	{0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E},
+	{0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27B0, 0x27B0}, {0x27BF, 0x27BF}, {0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF}, {0xA960, 0xA97F}, {0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF}, {0xFE10, 0xFE19}, {0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A},
+	{0x1F200, 0x1F251}, {0x1F300, 0x1F64F}, {0x1F680, 0x1F6FF},
+	{0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
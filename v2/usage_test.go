@@ -0,0 +1,68 @@
+package opts
+
+import "testing"
+
+var usageOpts = []LongOpt{
+	{Short: 'a', Long: "add", Arg: Required, Help: "add an item"},
+	{Short: 'ß', Long: "sheiße", Arg: None, Help: "be rude"},
+	{Short: 'λ', Long: "λεωνίδας", Arg: None},
+	{Short: -1, Long: "no-short", Arg: None, Help: "an option without a short-form"},
+	{Short: '日', Long: "日本語", Arg: Optional, ArgName: "when",
+		Help: "a wide option\nspanning lines", Default: "never"},
+	{Short: 'c', Arg: Required, ArgName: "file"},
+}
+
+func TestSynopsis(t *testing.T) {
+	want := "[-ßλ] [-a arg] [-日[when]] [-c file] [--no-short]"
+	if s := Synopsis(usageOpts); s != want {
+		die(t, "Synopsis()", want, s)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	want := `  -a, --add=arg         add an item
+  -ß, --sheiße          be rude
+  -λ, --λεωνίδας
+      --no-short        an option without a short-form
+  -日, --日本語[=when]  a wide option
+                        spanning lines (default: never)
+  -c file
+`
+	if s := Help(usageOpts); s != want {
+		die(t, "Help()", want, s)
+	}
+}
+
+func TestUsageNegatable(t *testing.T) {
+	opts := []LongOpt{
+		{Short: 'c', Long: "color", Arg: None, Negatable: true},
+		{Short: -1, Long: "pager", Arg: None, Negatable: true},
+	}
+	want := "[-c] [--[no-]pager]"
+	if s := Synopsis(opts); s != want {
+		die(t, "Synopsis()", want, s)
+	}
+	want = "  -c, --[no-]color\n      --[no-]pager\n"
+	if s := Help(opts); s != want {
+		die(t, "Help()", want, s)
+	}
+}
+
+func TestUsageZeroShort(t *testing.T) {
+	opts := []LongOpt{
+		{Short: 'v', Long: "verbose", Arg: None},
+		{Short: 0, Long: "zero", Arg: None},
+	}
+	want := "[-v] [--zero]"
+	if s := Synopsis(opts); s != want {
+		die(t, "Synopsis()", want, s)
+	}
+	want = "  -v, --verbose\n      --zero\n"
+	if s := Help(opts); s != want {
+		die(t, "Help()", want, s)
+	}
+	want = "[-v | --zero]"
+	if s := Synopsis(opts, Exclusive("verbose", "zero")); s != want {
+		die(t, "Synopsis()", want, s)
+	}
+}